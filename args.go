@@ -0,0 +1,131 @@
+package main
+
+import (
+	"edlin/textlist"
+	"edlin/textlist/buffer"
+	"flag"
+	"fmt"
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+/*
+
+  File:    args.go
+  Author:  Bob Shofner
+
+  MIT License - https://opensource.org/license/mit/
+
+  This permission notice shall be included in all copies
+    or substantial portions of the Software.
+
+*/
+/*
+  Description: files given on the command line.
+	Each file opens in a tab. file:line opens at a line (1 based), and
+	- reads stdin into a tab without a file, so EDLIN may end a shell
+	pipeline, or be the $EDITOR. A file that does not exist is created
+	when the tab is saved.
+*/
+
+// fileArg is a file (or stdin) given on the command line
+type fileArg struct {
+	Path  string `json:"path,omitempty"`
+	Line  int    `json:"line,omitempty"`  // 1 based, 0 is not given
+	Stdin []byte `json:"stdin,omitempty"` // the content of -
+}
+
+func usage() {
+	out := flag.CommandLine.Output()
+	_, _ = fmt.Fprintln(out, "usage: edlin [--readonly] [--new] [--single [--wait]] [file[:line] | -] ...")
+	_, _ = fmt.Fprintln(out, "       edlin -s script.ed file ...")
+	_, _ = fmt.Fprintln(out, "Flags may come before or after the files. After -- each is a file.")
+	flag.PrintDefaults()
+}
+
+// parseFlags parses the flags before and after the files (flag.Parse stops at the first),
+// so "edlin notes.txt --readonly" is read only. Everything after "--" is a file.
+func parseFlags(args []string) (files []string) {
+	for {
+		_ = flag.CommandLine.Parse(args) // ExitOnError
+		rest := flag.Args()
+		if len(rest) == 0 {
+			return files
+		}
+		if len(rest) < len(args) && args[len(args)-len(rest)-1] == "--" {
+			return append(files, rest...)
+		}
+		files = append(files, rest[0])
+		args = rest[1:]
+	}
+}
+
+// parseArgs splits the line from each file, and reads stdin for -
+func parseArgs(args []string) ([]fileArg, error) {
+	var files []fileArg
+	for _, arg := range args {
+		if arg == "-" {
+			data, err := io.ReadAll(os.Stdin)
+			if err != nil {
+				return nil, fmt.Errorf("stdin: %w", err)
+			}
+			files = append(files, fileArg{Stdin: data})
+			continue
+		}
+		f := fileArg{Path: arg}
+		if _, err := os.Stat(arg); err != nil {
+			// not a file, so perhaps file:line
+			if ix := strings.LastIndexByte(arg, ':'); ix > 0 {
+				if line, err := strconv.Atoi(arg[ix+1:]); err == nil && line > 0 {
+					f.Path, f.Line = arg[:ix], line
+				}
+			}
+		}
+		path, err := filepath.Abs(f.Path)
+		if err != nil {
+			return nil, err
+		}
+		f.Path = path
+		files = append(files, f)
+	}
+	return files, nil
+}
+
+// openArgs opens a tab for each file given on the command line, returning their items
+func openArgs(w fyne.Window, theme *textlist.MyTheme, files []fileArg, readOnly bool) (items []*container.TabItem) {
+	legacy := fyne.CurrentApp().Preferences().StringWithFallback(legacyEncodingKey,
+		buffer.DefaultLegacyEncoding)
+	for _, f := range files {
+		var t *tab
+		switch {
+		case f.Stdin != nil || f.Path == "":
+			b, err := buffer.NewBufferEncoding(f.Stdin, legacy)
+			if err != nil {
+				dialog.ShowError(fmt.Errorf("stdin: %w", err), w)
+				continue
+			}
+			b.SetModified(!readOnly) // only in the tab, until saved
+			t = bufferTab(w, theme, "", "stdin", b)
+		default:
+			if _, err := os.Stat(f.Path); os.IsNotExist(err) {
+				t = bufferTab(w, theme, f.Path, filepath.Base(f.Path), buffer.NewBuffer())
+			} else if t, err = openFile(w, theme, f.Path); err != nil {
+				dialog.ShowError(err, w)
+				continue
+			}
+		}
+		editor := t.editor
+		editor.SetReadOnly(readOnly)
+		if f.Line > 0 {
+			editor.MoveToRow(f.Line - 1)
+		}
+		items = append(items, t.item)
+	}
+	return items
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"bytes"
+	"edlin/textlist"
+	"errors"
+	"fmt"
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+/*
+
+  File:    drop.go
+  Author:  Bob Shofner
+
+  MIT License - https://opensource.org/license/mit/
+
+  This permission notice shall be included in all copies
+    or substantial portions of the Software.
+
+*/
+/*
+  Description: files dragged onto the window.
+	Each file opens in a tab, as with File > Open. A folder asks to
+	open the text files in it. A single file dropped onto the rows of
+	a tab asks to Insert it at that row, or to Open it.
+*/
+
+// fileDropped handles files and folders dropped onto the window
+func fileDropped(w fyne.Window, theme *textlist.MyTheme, pos fyne.Position, uris []fyne.URI) {
+	var files, dirs []string
+	for _, uri := range uris {
+		if uri.Scheme() != "file" {
+			continue
+		}
+		info, err := os.Stat(uri.Path())
+		switch {
+		case err != nil:
+			dialog.ShowError(err, w)
+		case info.IsDir():
+			dirs = append(dirs, uri.Path())
+		default:
+			files = append(files, uri.Path())
+		}
+	}
+
+	if t := windowOf(w).current(); len(files) == 1 && len(dirs) == 0 && t != nil {
+		if row, ok := t.editor.RowAt(pos); ok && !t.editor.ReadOnly() {
+			confirmInsert(w, theme, t, files[0], row)
+			return
+		}
+	}
+	openFiles(w, theme, files)
+	for _, dir := range dirs {
+		openFolder(w, theme, dir)
+	}
+}
+
+// openFiles opens each file in a tab
+func openFiles(w fyne.Window, theme *textlist.MyTheme, files []string) {
+	var errs []error
+	for _, path := range files {
+		_, err := openFile(w, theme, path)
+		errs = append(errs, err)
+	}
+	if err := errors.Join(errs...); err != nil {
+		dialog.ShowError(err, w)
+	}
+}
+
+// openFolder asks to open the text files of a folder (not its sub folders)
+func openFolder(w fyne.Window, theme *textlist.MyTheme, dir string) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		dialog.ShowError(err, w)
+		return
+	}
+	var files []string
+	for _, e := range entries {
+		path := filepath.Join(dir, e.Name())
+		if e.Type().IsRegular() && isTextFile(path) {
+			files = append(files, path)
+		}
+	}
+	if len(files) == 0 {
+		dialog.ShowInformation("Open Folder", "No text files in "+dir, w)
+		return
+	}
+	dialog.ShowConfirm("Open Folder", fmt.Sprintf("Open the %d text files in %s?", len(files), dir),
+		func(ok bool) {
+			if ok {
+				openFiles(w, theme, files)
+			}
+		}, w)
+}
+
+// confirmInsert asks to insert a file at a row of a tab, or to open it
+func confirmInsert(w fyne.Window, theme *textlist.MyTheme, t *tab, path string, row int) {
+	content := widget.NewLabel(fmt.Sprintf("Insert %s at line %d of %s,\nor Open it in a new tab?",
+		filepath.Base(path), row+1, t.title))
+	d := dialog.NewCustomWithoutButtons("Dropped File", content, w)
+	d.SetButtons([]fyne.CanvasObject{
+		widget.NewButton("Cancel", d.Hide),
+		widget.NewButton("Open", func() {
+			d.Hide()
+			openFiles(w, theme, []string{path})
+		}),
+		&widget.Button{Text: "Insert", Importance: widget.HighImportance, OnTapped: func() {
+			d.Hide()
+			buffer, err := readFile(path)
+			if err != nil {
+				dialog.ShowError(err, w)
+				return
+			}
+			t.editor.Insert(row, buffer.Lines(0, buffer.Len()-1)...)
+		}},
+	})
+	d.Show()
+}
+
+// isTextFile reports whether a file looks like text: it has a BOM, or no NUL bytes
+func isTextFile(path string) bool {
+	f, err := os.Open(path)
+	if err != nil {
+		return false
+	}
+	defer func(f *os.File) {
+		_ = f.Close()
+	}(f)
+	data := make([]byte, 8000)
+	n, err := io.ReadFull(f, data)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+		return false
+	}
+	data = data[:n]
+	for _, bom := range [][]byte{{0xef, 0xbb, 0xbf}, {0xff, 0xfe}, {0xfe, 0xff}, {0, 0, 0xfe, 0xff}} {
+		if bytes.HasPrefix(data, bom) {
+			return true
+		}
+	}
+	return !bytes.Contains(data, []byte{0})
+}
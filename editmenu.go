@@ -20,28 +20,42 @@ import (
   Description:
 */
 
-var typeShortcut = func(keyName fyne.KeyName) {
-	cs := desktop.CustomShortcut{keyName, fyne.KeyModifierControl}
-	tabs[tabix].editor.TypedShortcut(&cs)
+// typeShortcut types a Ctrl shortcut into the current tab of the window
+func typeShortcut(w fyne.Window, keyName fyne.KeyName) {
+	if t := windowOf(w).current(); t != nil {
+		cs := desktop.CustomShortcut{KeyName: keyName, Modifier: fyne.KeyModifierControl}
+		t.editor.TypedShortcut(&cs)
+	}
 }
 
-func createEditMenu() *fyne.Menu {
+func createEditMenu(w fyne.Window) *fyne.Menu {
 	menu := fyne.NewMenu("Edit",
+		fyne.NewMenuItem("Undo  ^Z", func() {
+			typeShortcut(w, "Z")
+		}),
+		fyne.NewMenuItem("Redo  ^Y", func() {
+			typeShortcut(w, "Y")
+		}),
+		fyne.NewMenuItemSeparator(),
 		fyne.NewMenuItem("Begin ^M", func() {
-			typeShortcut("M")
+			typeShortcut(w, "M")
 		}),
 		fyne.NewMenuItem("End   ^E", func() {
-			typeShortcut("E")
+			typeShortcut(w, "E")
 		}),
 		fyne.NewMenuItemSeparator(),
 		fyne.NewMenuItem("Cut   ^X", func() {
-			typeShortcut("X")
+			typeShortcut(w, "X")
 		}),
 		fyne.NewMenuItem("Copy  ^C", func() {
-			typeShortcut("C")
+			typeShortcut(w, "C")
 		}),
 		fyne.NewMenuItem("Paste ^V", func() {
-			typeShortcut("V")
+			typeShortcut(w, "V")
+		}),
+		fyne.NewMenuItemSeparator(),
+		fyne.NewMenuItem("Command ^L", func() {
+			typeShortcut(w, "L")
 		}),
 	)
 	return menu
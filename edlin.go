@@ -2,13 +2,19 @@ package main
 
 import (
 	"edlin/textlist"
+	"edlin/textlist/buffer"
+	"flag"
 	"fmt"
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/app"
 	"fyne.io/fyne/v2/canvas"
 	"fyne.io/fyne/v2/container"
 	"fyne.io/fyne/v2/data/binding"
+	"net"
 	"os"
+	"path/filepath"
+	"slices"
+	"strings"
 	"time"
 )
 
@@ -28,63 +34,109 @@ import (
 
 fyne package --release --icon=typewriter.png --id=com.scsi.edlin
 
+edlin [--readonly] [--new] [file[:line] | -] ...   opens the files (- is stdin) in tabs.
+edlin --single [--wait] file ...   opens the files in the running EDLIN (and waits for their tabs to close).
+edlin -s script.ed file ...   runs an EDLIN command script, without a window.
+
 */
 
 type tab struct {
+	id        int // stable, as titles and positions change
 	editor    *textlist.TextList
 	container *fyne.Container
-	title     string
+	item      *container.TabItem
+	win       *editorWindow // the tab is shown in
+	name      string        // of the file, or "New"
+	title     string        // the name, and the folders that tell same-named files apart
 	path      string
+	journal   string // crash recovery file
+	journaled int    // Buffer Changes when last journaled
+	banner    *banner
+	diskTime  time.Time // of the file, when read or saved
+	diskSize  int64
 }
 
 func main() {
-	a := app.NewWithID("com.scsi.edlin")
-	w := a.NewWindow("EDLIN by Bob")
-	w.SetIcon(resourceTypewriterPng)
-
-	theme := textlist.NewTheme(a.Settings(), a.Preferences())
-	tabItems = container.NewDocTabs()
-	tabItems.SetTabLocation(container.TabLocationTop)
-	tabItems.OnSelected = func(item *container.TabItem) {
-		selectTabItem(item)
+	script := flag.String("s", "", "run the EDLIN command `script` against each file, without a window")
+	readOnly := flag.Bool("readonly", false, "open the files read only")
+	newFile := flag.Bool("new", false, "open a new (empty) tab")
+	single := flag.Bool("single", false, "open the files in the running EDLIN, if any")
+	wait := flag.Bool("wait", false, "with --single, end when the tabs are closed")
+	flag.Usage = usage
+	files := parseFlags(os.Args[1:])
+	if *script != "" {
+		os.Exit(runScript(*script, files))
 	}
-	tabItems.OnClosed = func(item *container.TabItem) {
-		removeTabItem(item)
+	args, err := parseArgs(files)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "edlin:", err)
+		os.Exit(2)
 	}
 
-	w.Resize(fyne.NewSize(float32(theme.Width), float32(theme.Height)))
-
-	// Set the main menu
-	w.SetMainMenu(fyne.NewMainMenu(
-		createFileMenu(w, theme),
-		createEditMenu(),
-		createHelpMenu(w)))
-
+	a := app.NewWithID("com.scsi.edlin")
+	*single = *single || *wait || a.Preferences().Bool(singleInstanceKey)
+	if *single && forward(instanceRequest{Files: args, ReadOnly: *readOnly, New: *newFile, Wait: *wait}) {
+		return
+	}
+	theme := textlist.NewTheme(a.Settings(), a.Preferences())
+	tabTheme = theme
 	setDefaultPaths(a.Preferences())
+	ew := newWindow(theme)
+	w := ew.window
 
 	image := canvas.NewImageFromResource(resourceTypewriterPng)
 	image.FillMode = canvas.ImageFillContain
 	box := container.NewStack(image)
 
+	// files on the command line are opened without the splash, and instead of the last session
+	splash := time.Millisecond * 2500
+	if len(args) > 0 || *newFile {
+		splash = 0
+	}
 	go func() {
-		time.Sleep(time.Millisecond * 2500)
+		time.Sleep(splash)
 		fyne.Do(func() {
-			box.Objects[0] = tabItems
+			box.Objects[0] = ew.tabItems
 			box.Refresh()
 			image = nil
+			if splash > 0 {
+				restoreSession(w, theme)
+			}
+			openArgs(w, theme, args, *readOnly)
+			if *newFile {
+				newTab(w, theme)
+			}
+			recoverTabs(w, theme)
 		})
 	}()
+	startAutosave(a.Preferences())
+	startWatcher()
+	if *single {
+		if listener := listen(theme); listener != nil {
+			defer func(listener net.Listener) {
+				_ = listener.Close()
+			}(listener)
+		}
+	}
+
+	a.Lifecycle().SetOnStopped(saveSession) // every window, including a Quit from the menu
 
 	w.SetContent(box)
-	w.ShowAndRun()
+	w.Show()
+	a.Run()
 
 }
 
-var tabItems *container.DocTabs
-var tabix int
-var tabs = make([]tab, 0)
-var tabMap = make(map[string]int)
-var nextSequence = 1
+const appTitle = "EDLIN by Bob"
+
+var tabTheme *textlist.MyTheme
+var tabMap = make(map[*container.TabItem]*tab)
+var nextTabID = 1
+
+// tabDragStep is how far the name of a tab is dragged to move it one place.
+// Dragging it down tabTearOff moves it to a new window.
+const tabDragStep = 100
+const tabTearOff = 150
 
 var buttonBar *fyne.Container
 var openPath = binding.NewString()
@@ -123,43 +175,186 @@ func setDefaultPaths(prefs fyne.Preferences) {
 	}))
 }
 
-func selectTabItem(item *container.TabItem) {
-	if tx, ok := tabMap[item.Text]; ok {
-		tabix = tx
+// syncTabs orders the tabs of each window as shown, and titles them
+func syncTabs() {
+	for _, ew := range windows {
+		ew.tabs = ew.tabs[:0]
+		for _, item := range ew.tabItems.Items {
+			if t, ok := tabMap[item]; ok {
+				ew.tabs = append(ew.tabs, t)
+			}
+		}
+		ew.tabix = max(ew.tabItems.SelectedIndex(), 0)
+	}
+	titleTabs()
+	showTitles()
+}
+
+// closeTab removes a TabItem, without asking to save
+func closeTab(item *container.TabItem) {
+	if t, ok := tabMap[item]; ok {
+		t.win.tabItems.Remove(item)
 	}
+	removeTabItem(item)
 }
 
 func removeTabItem(item *container.TabItem) {
-	if tx, ok := tabMap[item.Text]; ok {
-		if len(tabs) < 2 {
-			tabix = 0
-			tabs = nil
-		} else if tx == len(tabs)-1 {
-			tabsNew := tabs[:tx]
-			tabs = tabsNew
-			tabix = len(tabs) - 1
-		} else {
-			tabsNew := append(tabs[:tx], tabs[tx+1:]...)
-			tabs = tabsNew
-			tabix = tx
-			if tabix > 0 {
-				tabix--
+	tabClosed(item)
+	if t, ok := tabMap[item]; ok {
+		removeJournal(t)
+		unwatchFile(t.path)
+		delete(tabMap, item)
+	}
+	syncTabs()
+}
+
+// moveTab moves a tab to position to (0 is first) of its window
+func moveTab(t *tab, to int) {
+	ew := t.win
+	to = min(max(to, 0), len(ew.tabs)-1)
+	if from := slices.Index(ew.tabs, t); from < 0 || to == from {
+		return
+	}
+	items := slices.DeleteFunc(slices.Clone(ew.tabItems.Items), func(item *container.TabItem) bool {
+		return item == t.item
+	})
+	ew.tabItems.SetItems(slices.Insert(items, to, t.item))
+	ew.tabItems.Select(t.item)
+	syncTabs()
+}
+
+// allTabs returns the tabs of every window
+func allTabs() (all []*tab) {
+	for _, ew := range windows {
+		all = append(all, ew.tabs...)
+	}
+	return all
+}
+
+// titleTabs titles each tab by its name, adding the folders that tell same-named files apart.
+// Same-named tabs without a file are numbered.
+func titleTabs() {
+	named := make(map[string][]*tab)
+	for _, t := range allTabs() {
+		named[t.name] = append(named[t.name], t)
+	}
+	for _, same := range named {
+		if len(same) == 1 {
+			same[0].title = same[0].name
+			continue
+		}
+		slices.SortFunc(same, func(a, b *tab) int {
+			return a.id - b.id
+		})
+		n := 0
+		seen := make(map[string]int) // the same file, in more than one tab
+		for _, t := range same {
+			if t.path == "" {
+				n++
+				t.title = fmt.Sprintf("%s %d", t.name, n)
+				continue
+			}
+			t.title = t.name + " - " + distinctFolder(t, same)
+			if seen[t.title]++; seen[t.title] > 1 {
+				t.title += fmt.Sprintf(" %d", seen[t.title])
 			}
 		}
 	}
-	delete(tabMap, item.Text)
 }
 
-func addTab(t tab) {
-	// insure unique title
-	if _, ok := tabMap[t.title]; ok {
-		t.title = fmt.Sprintf("%s(%d)", t.title, nextSequence)
-		nextSequence++
-	}
-	tabs = append(tabs, t)
-	tabItem := container.NewTabItem(t.title, t.container)
-	tabItems.Append(tabItem)
-	tabItems.Select(tabItem)
-	tabix = len(tabs) - 1
-	tabMap[t.title] = tabix
+// distinctFolder returns the last folders of the path of t, enough to differ from the others
+func distinctFolder(t *tab, same []*tab) string {
+	folders := strings.Split(filepath.ToSlash(filepath.Dir(t.path)), "/")
+	for n := 1; n < len(folders); n++ {
+		suffix := strings.Join(folders[len(folders)-n:], "/")
+		unique := true
+		for _, o := range same {
+			if o != t && o.path != "" && strings.HasSuffix(filepath.ToSlash(filepath.Dir(o.path)), "/"+suffix) {
+				unique = false
+				break
+			}
+		}
+		if unique {
+			return suffix
+		}
+	}
+	return filepath.Dir(t.path)
+}
+
+// tabText is the title of a tab, with a * when it has unsaved changes
+func tabText(t *tab) string {
+	if t.editor.Modified() {
+		return "*" + t.title
+	}
+	return t.title
+}
+
+// showTitles shows the modified tabs, and the current tab in the title of each window
+func showTitles() {
+	for _, ew := range windows {
+		for _, t := range ew.tabs {
+			t.item.Text = tabText(t)
+		}
+		ew.tabItems.Refresh()
+		title := appTitle
+		if t := ew.current(); t != nil {
+			title += " - " + tabText(t)
+		}
+		ew.window.SetTitle(title)
+	}
+}
+
+// addTab adds a tab to a window, and selects it
+func addTab(ew *editorWindow, t *tab) {
+	t.win = ew
+	t.id = nextTabID
+	nextTabID++
+	t.journal = newJournal()
+	t.journaled = -1
+	t.item = container.NewTabItem(t.name, nil)
+	t.banner = newBanner(t.item)
+	t.item.Content = container.NewBorder(t.banner.box, nil, nil, nil, t.container)
+	t.editor.OnModified = func(bool) {
+		focused = t.win
+		showTitles()
+	}
+	// dragging the name above the list moves the tab, and down moves it to a new window
+	var dragged, down float32
+	t.editor.OnNameDragged = func(dx, dy float32) {
+		dragged += dx
+		down += dy
+		if step := int(dragged / tabDragStep); step != 0 {
+			dragged -= float32(step) * tabDragStep
+			moveTab(t, slices.Index(t.win.tabs, t)+step)
+		}
+	}
+	t.editor.OnNameDragEnd = func() {
+		if down > tabTearOff {
+			moveToNewWindow(t)
+		}
+		dragged, down = 0, 0
+	}
+	t.editor.OnNameTappedSecondary = func(pos fyne.Position) {
+		showTabMenu(t.win.window, tabTheme, t, pos)
+	}
+	// the search results pane is exported to a new tab
+	t.editor.OnExportResults = func(lines []string) {
+		bufferTab(t.win.window, tabTheme, "", "Results", buffer.NewBuffer(lines...))
+	}
+	tabMap[t.item] = t
+	statTab(t)
+	watchFile(t.path)
+	ew.tabItems.Append(t.item)
+	ew.tabItems.Select(t.item)
+	syncTabs()
+}
+
+// setTabPath changes the file of a tab (Save As), and its title
+func setTabPath(t *tab, path string) {
+	unwatchFile(t.path)
+	watchFile(path)
+	t.path = path
+	t.name = filepath.Base(path)
+	t.editor.SetName(path)
+	syncTabs()
 }
@@ -1,13 +1,18 @@
 package main
 
 import (
-	"bufio"
 	"edlin/textlist"
+	"edlin/textlist/buffer"
+	"errors"
+	"fmt"
 	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
 	"fyne.io/fyne/v2/dialog"
 	"fyne.io/fyne/v2/storage"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
 	"io"
-	"log"
+	"os"
 	"path/filepath"
 	"strings"
 )
@@ -31,25 +36,123 @@ func createFileMenu(w fyne.Window, theme *textlist.MyTheme) *fyne.Menu {
 
 	fileMenu := fyne.NewMenu("File",
 		fyne.NewMenuItem("New  ...", func() {
-			var t tab
-			t.title = "New"
-			t.editor, t.container = fileNew(w, t.title, *theme)
-			addTab(t)
+			newTab(w, theme)
+		}),
+		fyne.NewMenuItem("New Window", func() {
+			newEditorWindow(theme)
 		}),
 		fyne.NewMenuItem("Open ...", func() {
 			fileOpen(w, theme)
 		}),
-		fyne.NewMenuItem("Save ...", func() {
-			fileSave(w, tabix)
+		createRecentMenu(w),
+		fyne.NewMenuItem("Save  ^S", func() {
+			if t := windowOf(w).current(); t != nil {
+				fileSave(w, t)
+			}
+		}),
+		fyne.NewMenuItem("Save As ...", func() {
+			if t := windowOf(w).current(); t != nil {
+				fileSaveAs(w, t, nil)
+			}
+		}),
+		fyne.NewMenuItem("Save All", func() {
+			fileSaveAll(w)
 		}),
-
-		// fyne.NewMenuItem("Save All  ", func() { fmt.Println("Save   ") }),
 		fyne.NewMenuItemSeparator(),
+		createLineEndingMenu(w),
+		createEncodingMenu(w),
+		createBackupMenu(),
+		createSessionMenu(),
 	)
 
 	return fileMenu
 }
 
+// createLineEndingMenu converts the line endings of the tab, when next saved
+func createLineEndingMenu(w fyne.Window) *fyne.MenuItem {
+	var items []*fyne.MenuItem
+	for _, ending := range []buffer.LineEnding{buffer.LF, buffer.CRLF, buffer.CR} {
+		items = append(items, fyne.NewMenuItem(ending.String(), func() {
+			t := windowOf(w).current()
+			if t == nil {
+				return
+			}
+			f := t.editor.Format()
+			f.Ending = ending
+			t.editor.SetFormat(f)
+		}))
+	}
+	menu := fyne.NewMenuItem("Line Endings", nil)
+	menu.ChildMenu = fyne.NewMenu("Line Endings", items...)
+	return menu
+}
+
+const backupKey = "backup"
+
+// createBackupMenu keeps the previous version of a saved file as name.bak
+func createBackupMenu() *fyne.MenuItem {
+	prefs := fyne.CurrentApp().Preferences()
+	item := fyne.NewMenuItem("Keep .BAK", nil)
+	item.Checked = prefs.Bool(backupKey)
+	item.Action = func() { // the preference, as another window may have changed it
+		item.Checked = !prefs.Bool(backupKey)
+		prefs.SetBool(backupKey, item.Checked)
+	}
+	return item
+}
+
+const legacyEncodingKey = "legacyEncoding"
+
+// createEncodingMenu converts the encoding of the tab, when next saved.
+// "Open Legacy As" chooses the encoding of opened files that are not UTF-8.
+func createEncodingMenu(w fyne.Window) *fyne.MenuItem {
+	var items []*fyne.MenuItem
+	encodings := append(append([]string(nil), buffer.UnicodeEncodings...), buffer.LegacyEncodings...)
+	for _, name := range encodings {
+		items = append(items, fyne.NewMenuItem(name, func() {
+			t := windowOf(w).current()
+			if t == nil {
+				return
+			}
+			f := t.editor.Format()
+			if f.Encoding != name && name != buffer.UTF8 {
+				f.BOM = buffer.IsUnicode(name) // UTF-16 and UTF-32 are found by their BOM
+			}
+			f.Encoding = name
+			t.editor.SetFormat(f)
+		}))
+	}
+
+	prefs := fyne.CurrentApp().Preferences()
+	legacy := fyne.NewMenu("Open Legacy As")
+	for _, name := range buffer.LegacyEncodings {
+		item := fyne.NewMenuItem(name, nil)
+		item.Checked = name == prefs.StringWithFallback(legacyEncodingKey, buffer.DefaultLegacyEncoding)
+		item.Action = func() {
+			prefs.SetString(legacyEncodingKey, name)
+			for _, i := range legacy.Items {
+				i.Checked = i.Label == name
+			}
+			legacy.Refresh()
+		}
+		legacy.Items = append(legacy.Items, item)
+	}
+	legacyItem := fyne.NewMenuItem("Open Legacy As", nil)
+	legacyItem.ChildMenu = legacy
+	items = append(items, fyne.NewMenuItemSeparator(), legacyItem)
+
+	menu := fyne.NewMenuItem("Encoding", nil)
+	menu.ChildMenu = fyne.NewMenu("Encoding", items...)
+	return menu
+}
+
+// newTab adds a new tab, without a file
+func newTab(w fyne.Window, theme *textlist.MyTheme) {
+	t := &tab{name: "New"}
+	t.editor, t.container = fileNew(w, t.name, *theme)
+	addTab(windowOf(w), t)
+}
+
 func fileNew(w fyne.Window, _ string, theme textlist.MyTheme) (editor *textlist.TextList, content *fyne.Container) {
 	editor, content = textlist.NewTextList(w, "", buttonBar, theme)
 	editor.SetContent("hello\"こんにちは世界\"World")
@@ -59,7 +162,7 @@ func fileNew(w fyne.Window, _ string, theme textlist.MyTheme) (editor *textlist.
 func fileOpen(w fyne.Window, theme *textlist.MyTheme) {
 	nfo := dialog.NewFileOpen(func(reader fyne.URIReadCloser, err error) {
 		if err != nil {
-			log.Println("fileOpen:", err.Error())
+			dialog.ShowError(err, w)
 			return
 		} else if reader == nil {
 			return
@@ -68,19 +171,13 @@ func fileOpen(w fyne.Window, theme *textlist.MyTheme) {
 			_ = reader.Close()
 		}(reader)
 
-		var t tab
-		t.path = reader.URI().Path()
-		t.title = filepath.Base(t.path)
-		t.editor, t.container = textlist.NewTextList(w, t.path, buttonBar, *theme)
-		scanner := bufio.NewScanner(reader)
-		var line string
-		for scanner.Scan() {
-			line = scanner.Text()
-			t.editor.AddString(line)
+		path := reader.URI().Path()
+		if _, err = loadTab(w, theme, path, reader); err != nil {
+			dialog.ShowError(fmt.Errorf("%s: %w", filepath.Base(path), err), w)
+			return
 		}
-
-		addTab(t)
-		_ = openPath.Set(filepath.Dir(t.path))
+		_ = openPath.Set(filepath.Dir(path))
+		addRecent(path)
 
 	}, w)
 
@@ -96,45 +193,211 @@ func fileOpen(w fyne.Window, theme *textlist.MyTheme) {
 	nfo.Show()
 }
 
-func fileSave(w fyne.Window, tabix int) {
-	nfs := dialog.NewFileSave(func(writer fyne.URIWriteCloser, err error) {
-		if err != nil {
-			log.Println("fileSave:", err.Error())
+// loadTab reads all of r (lines of any length) into a new tab for path
+func loadTab(w fyne.Window, theme *textlist.MyTheme, path string, r io.Reader) (*tab, error) {
+	legacy := fyne.CurrentApp().Preferences().StringWithFallback(legacyEncodingKey,
+		buffer.DefaultLegacyEncoding)
+	b, err := buffer.ReadBuffer(r, legacy)
+	if err != nil {
+		return nil, err
+	}
+
+	return bufferTab(w, theme, path, filepath.Base(path), b), nil
+}
+
+// readFile reads a file into a Buffer
+func readFile(path string) (*buffer.Buffer, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	legacy := fyne.CurrentApp().Preferences().StringWithFallback(legacyEncodingKey,
+		buffer.DefaultLegacyEncoding)
+	return buffer.NewBufferEncoding(data, legacy)
+}
+
+// bufferTab adds a tab editing buffer to the window
+func bufferTab(w fyne.Window, theme *textlist.MyTheme, path, name string, buffer *buffer.Buffer) *tab {
+	t := &tab{path: path, name: name}
+	t.editor, t.container = textlist.NewTextList(w, t.path, buttonBar, *theme)
+	t.editor.SetBuffer(buffer)
+	addTab(windowOf(w), t)
+	return t
+}
+
+// fileSave writes the tab to its file. A new tab is saved with Save As.
+// A read only tab is not written over its file: it may be saved with Save As.
+func fileSave(w fyne.Window, t *tab) {
+	if t.path == "" {
+		fileSaveAs(w, t, nil)
+		return
+	}
+	if t.editor.ReadOnly() {
+		dialog.ShowError(fmt.Errorf("%s is read only, use Save As", t.name), w)
+		return
+	}
+	if err := writeTab(t); err != nil {
+		dialog.ShowError(err, w)
+	}
+}
+
+// fileSaveAll writes every tab (of every window) with unsaved changes to its file.
+// New tabs, and read only tabs, are left for Save As.
+func fileSaveAll(w fyne.Window) {
+	var errs []error
+	for _, t := range allTabs() {
+		if t.path != "" && t.editor.Modified() && !t.editor.ReadOnly() {
+			errs = append(errs, writeTab(t))
+		}
+	}
+	if err := errors.Join(errs...); err != nil {
+		dialog.ShowError(err, w)
+	}
+}
+
+// writeTab writes lines with the tab's line endings, encoding, BOM and final newline
+func writeTab(t *tab) error {
+	if err := writeBuffer(t, t.path); err != nil {
+		return err
+	}
+	statTab(t)
+	return nil
+}
+
+// writeBuffer writes the tab to path, which is not yet its file for Save As
+func writeBuffer(t *tab, path string) error {
+	backup := fyne.CurrentApp().Preferences().Bool(backupKey)
+	if err := t.editor.Buffer().WriteFile(path, backup); err != nil {
+		return fmt.Errorf("%s: %w", filepath.Base(path), err)
+	}
+	t.editor.Buffer().SetModified(false)
+	removeJournal(t)
+	t.journaled = -1
+	return nil
+}
+
+// fileSaveAs chooses a new file for the tab, and writes it. saved (if any) is called after.
+// The file is chosen by folder and name: dialog.NewFileSave would create (truncate) it
+// before it is written, so the write would not replace it whole, or keep it as .bak.
+func fileSaveAs(w fyne.Window, t *tab, saved func()) {
+	folder := widget.NewEntry()
+	name := widget.NewEntry()
+	dir, _ := savePath.Get()
+	if t.path != "" {
+		dir = filepath.Dir(t.path)
+		name.SetText(filepath.Base(t.path))
+	}
+	folder.SetText(dir)
+	browse := widget.NewButtonWithIcon("", theme.FolderOpenIcon(), func() {
+		chooseFolder(w, folder)
+	})
+	items := []*widget.FormItem{
+		widget.NewFormItem("Folder", container.NewBorder(nil, nil, nil, browse, folder)),
+		widget.NewFormItem("Name", name),
+	}
+	d := dialog.NewForm("Save As", "Save", "Cancel", items, func(ok bool) {
+		if !ok || strings.TrimSpace(name.Text) == "" {
 			return
-		} else if writer == nil {
+		}
+		path, err := filepath.Abs(filepath.Join(folder.Text, name.Text))
+		if err != nil {
+			dialog.ShowError(err, w)
 			return
 		}
-		defer func(writer fyne.URIWriteCloser) {
-			_ = writer.Close()
-		}(writer)
-
-		// collect lines to string
-		var builder strings.Builder
-		iter := textlist.Iterator(tabs[tabix].editor)
-		for line := range iter {
-			bytes := []byte(line)
-			builder.Write(bytes)
-			builder.Write([]byte("\n"))
+		if _, err = os.Stat(path); err == nil && path != t.path {
+			dialog.ShowConfirm("Save As", path+" exists.\nReplace it?", func(replace bool) {
+				if replace {
+					saveTabAs(w, t, path, saved)
+				}
+			}, w)
+			return
 		}
-		reader := strings.NewReader(builder.String())
-		_, err = io.Copy(writer, reader)
+		saveTabAs(w, t, path, saved)
+	}, w)
+	d.Resize(fyne.NewSize(w.Canvas().Size().Width*0.6, d.MinSize().Height))
+	d.Show()
+	w.Canvas().Focus(name)
+}
+
+// chooseFolder sets the entry to a folder chosen with the folder dialog
+func chooseFolder(w fyne.Window, folder *widget.Entry) {
+	nfo := dialog.NewFolderOpen(func(uri fyne.ListableURI, err error) {
 		if err != nil {
-			log.Println("fileSave: io.Copy:", err.Error())
+			dialog.ShowError(err, w)
+			return
+		} else if uri == nil {
 			return
 		}
-
-		path := writer.URI().Path()
-		_ = savePath.Set(filepath.Dir(path))
+		folder.SetText(uri.Path())
 	}, w)
-
-	path, _ := savePath.Get()
-	uri := storage.NewFileURI(path)
 	// override last folder
-	fyne.CurrentApp().Preferences().SetString(lastFolderKey, path)
-	if listable, err := storage.ListerForURI(uri); err == nil {
-		nfs.SetLocation(listable)
+	fyne.CurrentApp().Preferences().SetString(lastFolderKey, folder.Text)
+	if listable, err := storage.ListerForURI(storage.NewFileURI(folder.Text)); err == nil {
+		nfo.SetLocation(listable)
+	}
+	nfo.SetView(dialog.ListView)
+	nfo.Resize(w.Canvas().Size())
+	nfo.Show()
+}
+
+// saveTabAs writes the tab to path, and only then makes path its file
+func saveTabAs(w fyne.Window, t *tab, path string, saved func()) {
+	if err := writeBuffer(t, path); err != nil {
+		dialog.ShowError(err, w)
+		return
+	}
+	setTabPath(t, path)
+	statTab(t)
+	_ = savePath.Set(filepath.Dir(path))
+	addRecent(path)
+	if saved != nil {
+		saved()
+	}
+}
+
+// confirmUnsaved asks to Save or Discard the unsaved tabs, before done (closing them).
+// Cancel, or a failed save, leaves them open.
+func confirmUnsaved(w fyne.Window, unsaved []*tab, done func()) {
+	if len(unsaved) == 0 {
+		done()
+		return
+	}
+	var titles []string
+	for _, t := range unsaved {
+		titles = append(titles, t.title)
+	}
+	content := widget.NewLabel("Save changes to:\n    " + strings.Join(titles, "\n    "))
+	d := dialog.NewCustomWithoutButtons("Unsaved Changes", content, w)
+	d.SetButtons([]fyne.CanvasObject{
+		widget.NewButton("Cancel", d.Hide),
+		widget.NewButton("Discard", func() {
+			d.Hide()
+			done()
+		}),
+		&widget.Button{Text: "Save", Importance: widget.HighImportance, OnTapped: func() {
+			d.Hide()
+			saveTabs(w, unsaved, done)
+		}},
+	})
+	d.Show()
+}
+
+// saveTabs saves each tab in turn (a new tab with Save As), then calls done
+func saveTabs(w fyne.Window, unsaved []*tab, done func()) {
+	if len(unsaved) == 0 {
+		done()
+		return
+	}
+	t := unsaved[0]
+	if t.path == "" {
+		fileSaveAs(w, t, func() {
+			saveTabs(w, unsaved[1:], done)
+		})
+		return
+	}
+	if err := writeTab(t); err != nil {
+		dialog.ShowError(err, w)
+		return
 	}
-	nfs.SetView(dialog.ListView)
-	nfs.Resize(w.Canvas().Size())
-	nfs.Show()
+	saveTabs(w, unsaved[1:], done)
 }
@@ -42,9 +42,14 @@ func createHelpMenu(w fyne.Window) *fyne.Menu {
 		d.Resize(w.Canvas().Size())
 		d.Show()
 	})
+	commandItem := fyne.NewMenuItem("Commands", func() {
+		d := dialog.NewInformation("EDLIN Commands", helpCommand, w)
+		d.Resize(w.Canvas().Size())
+		d.Show()
+	})
 	//	subMenu := fyne.NewMenu("HELP", fileMenuItem, editMenuItem, shortcutItem)
 	helpMenu := fyne.NewMenuItem("Help", nil)
-	helpMenu.ChildMenu = fyne.NewMenu("HELP", fileMenuItem, editMenuItem, shortcutItem, searchItem)
+	helpMenu.ChildMenu = fyne.NewMenu("HELP", fileMenuItem, editMenuItem, shortcutItem, searchItem, commandItem)
 
 	menu := fyne.NewMenu("Help", helpMenu,
 		fyne.NewMenuItem("About", func() {
@@ -60,14 +65,71 @@ var helpFile = `EDLIN Help:
 FileMenu:
 
 Open a new empty tab:       New  ...
+Open a new window:          New Window
 Open tab from a file:       Open ...
-Save tab contets to a file: Save ...
+Open a recent file:         Open Recent
+Save tab to its file:       Save  ^S
+Save tab to a new file:     Save As ...
+Save changed tabs to files: Save All
+     A read only tab (--readonly) is saved only with Save As.
+     A new tab (without a file) is saved with Save As.
+     A file is saved to a temporary file that replaces it when complete,
+     keeping its permissions. With "Keep .BAK" checked, the previous
+     version of name.txt is kept as name.bak.
+     Unsaved changes are journaled every 30 seconds (the "autosave"
+     preference, 0 is off). After a crash, EDLIN offers to Restore
+     them, showing the changes to the file on disk.
+     When another program changes the file of a tab, a tab without
+     unsaved changes is reloaded. Otherwise a banner offers to Reload
+     the file, Keep Mine (the tab), or Compare the two.
+     With "Restore Session" checked (it is off by default), the tabs
+     open when EDLIN closed are opened again, at the same row and
+     with the same search text.
+     A tab with unsaved changes is shown as *title. Closing it, or the
+     window, asks to Save or Discard the changes (or Cancel).
+
+Command line: edlin [--readonly] [--new] [file[:line] | -] ...
+     Opens each file in a tab (at line), - reads stdin into a tab.
+     A file that does not exist is created when saved. Flags may
+     come after the files; after -- each is a file.
+     --single sends the files to a running EDLIN (also the
+     "singleInstance" preference). --wait ends when their tabs close.
+
+Drag and drop: files dropped onto the window open in tabs. A folder
+     asks to open the text files in it. A file dropped onto the rows
+     of a tab asks to Insert it at that row, or Open it.
+
+Tabs: files with the same name are titled with their folders.
+     Drag the file name (top right of a tab) sideways to move the tab.
+     Right click the file name, or use the Tab menu, to Close Others,
+     Close to the Right, Close Saved, Copy Full (or Relative) Path,
+     Open Containing Folder, Duplicate Tab or Move to New Window.
+     Dragging the file name down also moves the tab to a new window.
+
+Windows: each window has its own tabs, and its menus and shortcuts act
+     on its tabs. A file already open (in any window) is selected
+     instead of opened again. Files sent by --single open in the window
+     last used. EDLIN ends when the last window closes, and its tabs
+     are the session restored at the next start.
+
+Line Endings: LF, CRLF or CR is used when the tab is next saved.
+Encoding:     The character encoding used when the tab is next saved.
+     The encoding, BOM, line ending and final newline of an opened file
+     are kept, and shown at the top left of the tab.
+     UTF-16 and UTF-32 files are found by their BOM. A file that is
+     not UTF-8 is read with the "Open Legacy As" encoding.
 `
 
 var helpEdit = `EDLIN Help:
 
 EditMenu: (Available only in Edit Mode.)
 
+Undo Change:    Undo  ^Z  or keyboard Ctrl + Z keys.
+Redo Change:    Redo  ^Y  or keyboard Ctrl + Y keys.
+     A Cut, Paste, line edit, replacement or EDLIN command
+     is undone as a single change.
+     (The number of changes kept is the "undoDepth" preference.)
+
 Mark Lines:
   Begin Line:   Begin ^M  or Keyboard Ctrl + M keys.
     End LIne:   End   ^E  or Keyboard Ctrl + E keys.
@@ -84,6 +146,11 @@ Paste Lines:    Paste ^V or keyboard Ctrl + V keys.
 
 Selecting (with mouse) a line allows editing of that line.
 The replacement entry may be 1 or many new lines.
+
+Long lines (over the "longLine" preference, 1000 characters) are
+wrapped, showing at most "longLineRows" rows (100). Select the line to edit all of it.
+A line longer than is shown (100,000 characters) is too long to edit in the entry:
+change it with the EDLIN commands (R, or D and I), or another editor.
 `
 
 var helpShortcut = `EDLIN Help:
@@ -92,6 +159,8 @@ Shortcut Keys:
 
 Ctrl + F or Ctrl + R enters Search/Replace Mode.
      ('x' in Search/Replace mode returns to Edit Mode.)
+Ctrl + L enters EDLIN Command Mode.
+     ('x' in Command mode returns to Edit Mode.)
 
 Ctrl + Home: Position the list at line 0.
 Ctrl + End:  Position the list at the last line.
@@ -106,13 +175,54 @@ var helpSearch = `EDLIN Help:
 Search / Replace:
 
 Enter text to find in the Search Box.
-Optionally choose Ignore Case.
+Optionally choose Ignore Case (full Unicode case folding, so ß
+matches SS), Whole Word (not part of a longer word), or Normalize
+(é matches e followed by a combining accent, and not e).
+Choose Regex to search with a Go regular expression (such as
+(\w+)@(\w+)). A match of no text (such as ^) is not found.
 Click on the search ICON.
 
-All the matches are found, and the first is highlighted.
+All the matches are found (each one on a line, not overlapping),
+and the first is highlighted. The count is of matches, not lines.
 Press the UP or DOWN arrows to advance to a previous or next match.
 
 If replacing : enter new text and press the confirm ICON. 
 Press the UP or DOWN arrows to advance to a previous or next match.
+With Regex, $1 (or ${1}) and ${name} in the new text are replaced by
+the groups of the match ($$ is a $).
+With Preserve Case, the new text takes the case of the match:
+foo becomes bar, Foo becomes Bar and FOO becomes BAR.
+
+Replace All lists every match, with its line before and after.
+Untick the matches to keep, and press Replace. Replace In Range
+does the same for the lines marked (^M, ^E) before searching.
+The replacements are undone (^Z) as one change.
+
+The LIST ICON shows (or hides) the results pane beside the lines.
+It lists each match as "line: text", and is kept up to date as the
+lines change. Tap a match to move to its line. The DOCUMENT ICON
+exports the matches (as file:line: text) to a new tab.
+
+`
 
+var helpCommand = `EDLIN Help:
+
+Commands: (Ctrl + L or Command ^L.)
+
+  [line]                      Edit line.
+  [line][,line]L              List (mark) lines.
+  [line][,line]P              Page lines, the last becomes current.
+  [line]I                     Insert before line.
+                                Enter "." alone to end inserting.
+  [line][,line]D              Delete lines.
+  [line],[line],line[,count]C Copy lines before line.
+  [line],[line],lineM         Move lines before line.
+  [line]Tfilename             Transfer a file before line.
+  [line][,line][?]Sstring     Search for string.
+  [line][,line][?]Rold^Znew   Replace old with new.
+                                ^Z is Ctrl+Z, or typed as ^Z.
+                                ? asks O.K.? before each.
+
+A line is a number, . (current line), # (after the last line),
+or +n / -n relative to the current line.
 `
@@ -0,0 +1,177 @@
+package main
+
+import (
+	"bufio"
+	"edlin/textlist"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"io/fs"
+	"log"
+	"net"
+	"os"
+	"path/filepath"
+	"syscall"
+	"time"
+)
+
+/*
+
+  File:    instance.go
+  Author:  Bob Shofner
+
+  MIT License - https://opensource.org/license/mit/
+
+  This permission notice shall be included in all copies
+    or substantial portions of the Software.
+
+*/
+/*
+  Description: single instance mode (--single, or the "singleInstance" preference).
+	The first EDLIN listens on a Unix socket. A later EDLIN sends its
+	files to it, to open as tabs, and ends. With --wait it ends when
+	those tabs are closed, as $EDITOR must (for a git commit message).
+*/
+
+const singleInstanceKey = "singleInstance"
+
+// instanceRequest is sent by a later EDLIN. A line "done" is the answer.
+type instanceRequest struct {
+	Files    []fileArg `json:"files"`
+	ReadOnly bool      `json:"readOnly,omitempty"`
+	New      bool      `json:"new,omitempty"`
+	Wait     bool      `json:"wait,omitempty"`
+}
+
+// closeWaiters are closed when their tab is closed
+var closeWaiters = make(map[*container.TabItem][]chan struct{})
+
+// instanceSocket is in a folder only the user may use, so another user can not listen in its place
+func instanceSocket() (string, error) {
+	dir := os.Getenv("XDG_RUNTIME_DIR")
+	if dir == "" {
+		cache, err := os.UserCacheDir()
+		if err != nil {
+			return "", err
+		}
+		dir = cache
+	}
+	dir = filepath.Join(dir, "edlin")
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", err
+	}
+	info, err := os.Lstat(dir)
+	if err != nil {
+		return "", err
+	}
+	if !info.IsDir() || info.Mode().Perm()&0077 != 0 {
+		return "", fmt.Errorf("%s is not private (%s)", dir, info.Mode())
+	}
+	return filepath.Join(dir, "instance.sock"), nil
+}
+
+// forward sends the request to a running EDLIN, and waits for its answer.
+// It reports false when no EDLIN is running.
+func forward(request instanceRequest) bool {
+	path, err := instanceSocket()
+	if err != nil {
+		return false
+	}
+	conn, err := net.DialTimeout("unix", path, time.Second)
+	if err != nil {
+		return false
+	}
+	defer func(conn net.Conn) {
+		_ = conn.Close()
+	}(conn)
+	if err = json.NewEncoder(conn).Encode(request); err != nil {
+		return false
+	}
+	// the answer comes once the tabs are open (or closed, with Wait)
+	answer, err := bufio.NewReader(conn).ReadString('\n')
+	return err == nil && answer == "done\n"
+}
+
+// listen makes this EDLIN the one that files are sent to
+func listen(theme *textlist.MyTheme) net.Listener {
+	path, err := instanceSocket()
+	if err != nil {
+		log.Println("single instance:", err.Error())
+		return nil
+	}
+	listener, err := net.Listen("unix", path)
+	if err != nil && staleSocket(path) {
+		if rerr := os.Remove(path); rerr == nil || errors.Is(rerr, fs.ErrNotExist) {
+			listener, err = net.Listen("unix", path)
+		}
+	}
+	if err != nil {
+		log.Println("single instance:", err.Error())
+		return nil
+	}
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return // closed
+			}
+			go serveInstance(theme, conn)
+		}
+	}()
+	return listener
+}
+
+// staleSocket tells if path is a socket left by a crash: it is refused, as nothing listens on it.
+// Any other error (such as a file that is not a socket) leaves path alone.
+func staleSocket(path string) bool {
+	conn, err := net.Dial("unix", path)
+	if err == nil {
+		_ = conn.Close() // an EDLIN started since forward tried it
+		return false
+	}
+	return errors.Is(err, syscall.ECONNREFUSED)
+}
+
+// serveInstance opens the tabs of a request in the last used window,
+// and answers once they are open (or closed, with Wait)
+func serveInstance(theme *textlist.MyTheme, conn net.Conn) {
+	defer func(conn net.Conn) {
+		_ = conn.Close()
+	}(conn)
+	var request instanceRequest
+	if err := json.NewDecoder(conn).Decode(&request); err != nil {
+		log.Println("single instance:", err.Error())
+		return
+	}
+	var closed []chan struct{}
+	fyne.DoAndWait(func() {
+		w := focused.window
+		items := openArgs(w, theme, request.Files, request.ReadOnly)
+		if request.New {
+			newTab(w, theme)
+			items = append(items, focused.current().item)
+		}
+		w.RequestFocus()
+		if request.Wait {
+			for _, item := range items {
+				c := make(chan struct{})
+				closeWaiters[item] = append(closeWaiters[item], c)
+				closed = append(closed, c)
+			}
+		}
+	})
+	for _, c := range closed {
+		<-c
+	}
+	_, _ = fmt.Fprintln(conn, "done")
+}
+
+// tabClosed ends the waits for a tab
+func tabClosed(item *container.TabItem) {
+	for _, c := range closeWaiters[item] {
+		close(c)
+	}
+	delete(closeWaiters, item)
+}
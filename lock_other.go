@@ -0,0 +1,37 @@
+//go:build !(linux || darwin || freebsd || netbsd || openbsd || dragonfly)
+
+package main
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+)
+
+/*
+
+  File:    lock_other.go
+  Author:  Bob Shofner
+
+  MIT License - https://opensource.org/license/mit/
+
+  This permission notice shall be included in all copies
+    or substantial portions of the Software.
+
+*/
+/*
+  Description: session locks without flock. Windows does not remove a
+	file that a process has open, and closes the files of a process
+	when it ends, so an open file is the lock.
+*/
+
+// lockFile creates path and keeps it open
+func lockFile(path string) (*os.File, error) {
+	return os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0600)
+}
+
+// locked tells if a running process holds path open. A lock that is not held is removed.
+func locked(path string) bool {
+	err := os.Remove(path)
+	return err != nil && !errors.Is(err, fs.ErrNotExist)
+}
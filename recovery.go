@@ -0,0 +1,320 @@
+package main
+
+import (
+	"bytes"
+	"edlin/textlist"
+	"edlin/textlist/buffer"
+	"encoding/json"
+	"fmt"
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+	"log"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+/*
+
+  File:    recovery.go
+  Author:  Bob Shofner
+
+  MIT License - https://opensource.org/license/mit/
+
+  This permission notice shall be included in all copies
+    or substantial portions of the Software.
+
+*/
+/*
+  Description: crash recovery.
+	Every "autosave" seconds, each tab with unsaved changes is written
+	to a journal in the app storage folder. A journal is removed when
+	its tab is saved or closed, so any found at startup are from a crash.
+	Each EDLIN holds a lock on a file of its session while it runs, so the
+	journals of another EDLIN still running are left alone.
+	Each is offered for restore, with its changes to the file on disk.
+*/
+
+const autosaveKey = "autosave"
+const journalExt = ".journal"
+const lockExt = ".lock"
+
+// journalHeader is the first line of a journal. The lines follow, one quoted line each (Buffer.WriteQuoted).
+type journalHeader struct {
+	Path   string        `json:"path"`
+	Title  string        `json:"title"`
+	Format buffer.Format `json:"format"`
+	Time   time.Time     `json:"time"`
+}
+
+// journalSession starts the name of each journal of this EDLIN, and names its lock
+var journalSession = fmt.Sprintf("%s-%d", time.Now().Format("20060102-150405"), os.Getpid())
+var journalSequence = 1
+var sessionLock *os.File // held open until EDLIN ends
+
+// newJournal names the journal of a new tab
+func newJournal() string {
+	name := fmt.Sprintf("%s-%d%s", journalSession, journalSequence, journalExt)
+	journalSequence++
+	return name
+}
+
+func journalDir() string {
+	return filepath.Join(fyne.CurrentApp().Storage().RootURI().Path(), "recovery")
+}
+
+// startAutosave journals the tabs every "autosave" seconds (0 is never)
+func startAutosave(prefs fyne.Preferences) {
+	err := os.MkdirAll(journalDir(), 0700)
+	if err == nil {
+		sessionLock, err = lockFile(filepath.Join(journalDir(), journalSession+lockExt))
+	}
+	if err != nil {
+		log.Println("journal lock:", err.Error())
+	}
+	seconds := prefs.IntWithFallback(autosaveKey, 30)
+	prefs.SetInt(autosaveKey, seconds)
+	if seconds <= 0 {
+		return
+	}
+	go func() {
+		for range time.Tick(time.Duration(seconds) * time.Second) {
+			fyne.Do(journalTabs)
+		}
+	}()
+}
+
+// journalWrite is the journal of a tab, as it is when the write starts
+type journalWrite struct {
+	t        *tab
+	path     string
+	buffer   *buffer.Buffer // the Buffer of the tab, to tell if it was replaced
+	snapshot *buffer.Buffer
+	header   journalHeader
+	err      error
+}
+
+var journalBusy bool // the journals are being written
+
+// journalTabs writes the journal of each tab changed since it was last journaled.
+// The Buffers are copied here, on the UI thread, and written on a goroutine.
+func journalTabs() {
+	if journalBusy {
+		return // the next tick writes the changes
+	}
+	var writes []*journalWrite
+	for _, t := range allTabs() {
+		buffer := t.editor.Buffer()
+		if !buffer.Modified() {
+			removeJournal(t)
+			continue
+		}
+		if buffer.Changes() == t.journaled {
+			continue
+		}
+		writes = append(writes, &journalWrite{
+			t:        t,
+			path:     filepath.Join(journalDir(), t.journal),
+			buffer:   buffer,
+			snapshot: buffer.Snapshot(),
+			header:   journalHeader{Path: t.path, Title: t.name, Format: buffer.Format(), Time: time.Now()},
+		})
+	}
+	if len(writes) == 0 {
+		return
+	}
+	journalBusy = true
+	go func() {
+		for _, j := range writes {
+			j.err = writeJournal(j.path, j.header, j.snapshot)
+		}
+		fyne.Do(func() {
+			journalBusy = false
+			for _, j := range writes {
+				journaled(j)
+			}
+		})
+	}()
+}
+
+// journaled records a journal written. One of a tab since closed, saved or reloaded is removed.
+func journaled(j *journalWrite) {
+	if j.err != nil {
+		log.Println("journal:", j.err.Error())
+		return
+	}
+	if !slices.Contains(allTabs(), j.t) || j.t.editor.Buffer() != j.buffer || !j.buffer.Modified() {
+		_ = os.Remove(j.path)
+		return
+	}
+	j.t.journaled = j.snapshot.Changes()
+}
+
+// writeJournal replaces the journal at path with the header and lines of a Buffer
+func writeJournal(path string, h journalHeader, b *buffer.Buffer) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	header, err := json.Marshal(h)
+	if err != nil {
+		return err
+	}
+	var data bytes.Buffer
+	data.Write(header)
+	data.WriteByte('\n')
+	if err = b.WriteQuoted(&data); err != nil {
+		return err
+	}
+	if err = os.WriteFile(path+".tmp", data.Bytes(), 0600); err != nil {
+		return err
+	}
+	return os.Rename(path+".tmp", path)
+}
+
+// removeJournal removes the journal of a tab (saved or closed)
+func removeJournal(t *tab) {
+	if t.journaled < 0 {
+		return // never written
+	}
+	_ = os.Remove(filepath.Join(journalDir(), t.journal))
+}
+
+// readJournal returns the header and lines of a journal
+func readJournal(path string) (h journalHeader, lines []string, err error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return h, nil, err
+	}
+	header, content, _ := bytes.Cut(data, []byte{'\n'})
+	if err = json.Unmarshal(header, &h); err != nil {
+		return h, nil, err
+	}
+	lines, err = buffer.ReadQuoted(bytes.NewReader(content))
+	return h, lines, err
+}
+
+// recoverTabs offers to restore each journal left by a crash
+func recoverTabs(w fyne.Window, theme *textlist.MyTheme) {
+	var journals []string
+	entries, _ := os.ReadDir(journalDir())
+	running := make(map[string]bool) // the sessions of each EDLIN running
+	for _, e := range entries {
+		if filepath.Ext(e.Name()) == lockExt {
+			path := filepath.Join(journalDir(), e.Name())
+			if session := strings.TrimSuffix(e.Name(), lockExt); session == journalSession || locked(path) {
+				running[session] = true
+			} else {
+				_ = os.Remove(path) // left by a crash
+			}
+		}
+	}
+	for _, e := range entries {
+		if filepath.Ext(e.Name()) == journalExt && !running[journalSessionOf(e.Name())] {
+			journals = append(journals, filepath.Join(journalDir(), e.Name()))
+		}
+	}
+	var next func(ix int)
+	next = func(ix int) {
+		if ix < len(journals) {
+			recoverTab(w, theme, journals[ix], func() {
+				next(ix + 1)
+			})
+		}
+	}
+	next(0)
+}
+
+// journalSessionOf returns the session that wrote a journal: its name, less the sequence
+func journalSessionOf(name string) string {
+	if ix := strings.LastIndexByte(name, '-'); ix > 0 {
+		return name[:ix]
+	}
+	return name
+}
+
+// recoverTab shows the changes of a journal to its file, to Restore, Discard or Keep (for later)
+func recoverTab(w fyne.Window, theme *textlist.MyTheme, path string, done func()) {
+	h, lines, err := readJournal(path)
+	if err != nil {
+		dialog.ShowConfirm("Recover", fmt.Sprintf("%s can not be read:\n%s\nDelete it?", filepath.Base(path), err),
+			func(ok bool) {
+				if ok {
+					_ = os.Remove(path)
+				}
+				done()
+			}, w)
+		return
+	}
+	onDisk, changes := fileLines(h.Path)
+	info := widget.NewLabel(fmt.Sprintf("Unsaved changes of %s (%s), %s:",
+		h.Title, h.Time.Format(time.DateTime), changes))
+	content := container.NewBorder(info, nil, nil, nil, diffView(onDisk, lines))
+
+	d := dialog.NewCustomWithoutButtons("Recover "+h.Title, content, w)
+	d.SetButtons([]fyne.CanvasObject{
+		widget.NewButton("Keep", func() {
+			d.Hide()
+			done()
+		}),
+		widget.NewButton("Discard", func() {
+			d.Hide()
+			_ = os.Remove(path)
+			done()
+		}),
+		&widget.Button{Text: "Restore", Importance: widget.HighImportance, OnTapped: func() {
+			d.Hide()
+			b := buffer.NewBuffer(lines...)
+			b.SetFormat(h.Format)
+			b.SetModified(true)
+			restoreTab(w, theme, h, b)
+			_ = os.Remove(path)
+			done()
+		}},
+	})
+	d.Resize(fyne.NewSize(w.Canvas().Size().Width*0.8, w.Canvas().Size().Height*0.8))
+	d.Show()
+}
+
+// restoreTab puts a recovered buffer in the tab its file is open in (as by the session), or a new tab
+func restoreTab(w fyne.Window, theme *textlist.MyTheme, h journalHeader, b *buffer.Buffer) {
+	for _, t := range allTabs() {
+		if h.Path != "" && t.path == h.Path {
+			row := t.editor.Row()
+			t.editor.SetBuffer(b)
+			t.editor.MoveToRow(row)
+			t.win.tabItems.Select(t.item)
+			showTitles()
+			return
+		}
+	}
+	bufferTab(w, theme, h.Path, h.Title, b)
+}
+
+// diffView shows the changes from the lines a to b
+func diffView(a, b []string) fyne.CanvasObject {
+	diff := buffer.Unified(buffer.Diff(a, b), 2)
+	const most = 500
+	if len(diff) > most {
+		diff = append(diff[:most], fmt.Sprintf("... %d more lines", len(diff)-most))
+	} else if len(diff) == 0 {
+		diff = []string{"(no changes)"}
+	}
+	text := widget.NewLabelWithStyle(strings.Join(diff, "\n"), fyne.TextAlignLeading, fyne.TextStyle{Monospace: true})
+	return container.NewScroll(text)
+}
+
+// fileLines reads the lines of the file a journal is compared to, and describes it
+func fileLines(path string) ([]string, string) {
+	if path == "" {
+		return nil, "a new tab"
+	}
+	buffer, err := readFile(path)
+	if err != nil {
+		return nil, err.Error()
+	}
+	return buffer.Lines(0, buffer.Len()-1), "compared to " + path
+}
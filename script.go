@@ -0,0 +1,51 @@
+package main
+
+import (
+	"bytes"
+	"edlin/textlist/buffer"
+	"fmt"
+	"os"
+)
+
+/*
+
+  File:    script.go
+  Author:  Bob Shofner
+
+  MIT License - https://opensource.org/license/mit/
+
+  This permission notice shall be included in all copies
+    or substantial portions of the Software.
+
+*/
+/*
+  Description: run an EDLIN command script against files, without a window.
+
+	edlin -s script.ed file ...
+*/
+
+// runScript applies the script to each file. It returns the exit status.
+func runScript(script string, files []string) int {
+	if len(files) == 0 {
+		_, _ = fmt.Fprintln(os.Stderr, "edlin: -s requires at least one file")
+		return 2
+	}
+	commands, err := os.ReadFile(script)
+	if err != nil {
+		_, _ = fmt.Fprintln(os.Stderr, "edlin:", err)
+		return 2
+	}
+
+	status := 0
+	for _, file := range files {
+		s, err := buffer.NewScript(file, os.Stdout)
+		if err == nil {
+			err = s.Run(bytes.NewReader(commands))
+		}
+		if err != nil {
+			_, _ = fmt.Fprintf(os.Stderr, "edlin: %s: %s\n", file, err)
+			status = 1
+		}
+	}
+	return status
+}
@@ -0,0 +1,184 @@
+package main
+
+import (
+	"edlin/textlist"
+	"encoding/json"
+	"fmt"
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/dialog"
+	"os"
+	"path/filepath"
+	"slices"
+)
+
+/*
+
+  File:    session.go
+  Author:  Bob Shofner
+
+  MIT License - https://opensource.org/license/mit/
+
+  This permission notice shall be included in all copies
+    or substantial portions of the Software.
+
+*/
+/*
+  Description: recent files and session restore.
+	The files opened or saved are kept, most recent first, in the
+	"recent" preference for File > Open Recent. The tabs open when the
+	last window closes are kept in the "session" preference, with their
+	current row, scroll position and search text, and are opened
+	again at startup when "Restore Session" is checked.
+*/
+
+const recentKey = "recent"
+const sessionKey = "session"
+const restoreSessionKey = "restoreSession"
+const maxRecent = 10
+
+var recentMenus = make(map[fyne.Window]*fyne.Menu) // the Open Recent menu of each window
+
+// sessionTab is a tab of the last session
+type sessionTab struct {
+	Path   string  `json:"path"`
+	Row    int     `json:"row"`
+	Offset float32 `json:"offset"`
+	Search string  `json:"search,omitempty"`
+}
+
+// createRecentMenu lists the recent files to open
+func createRecentMenu(w fyne.Window) *fyne.MenuItem {
+	recentMenus[w] = fyne.NewMenu("Open Recent")
+	showRecent()
+	item := fyne.NewMenuItem("Open Recent", nil)
+	item.ChildMenu = recentMenus[w]
+	return item
+}
+
+// showRecent fills the Open Recent menu of each window
+func showRecent() {
+	for w, menu := range recentMenus {
+		fillRecent(w, menu)
+	}
+}
+
+func fillRecent(w fyne.Window, recentMenu *fyne.Menu) {
+	recentMenu.Items = nil
+	for _, path := range fyne.CurrentApp().Preferences().StringList(recentKey) {
+		recentMenu.Items = append(recentMenu.Items, fyne.NewMenuItem(path, func() {
+			if _, err := openFile(w, tabTheme, path); err != nil {
+				dialog.ShowError(err, w)
+				removeRecent(path)
+			}
+		}))
+	}
+	if len(recentMenu.Items) == 0 {
+		none := fyne.NewMenuItem("(none)", nil)
+		none.Disabled = true
+		recentMenu.Items = append(recentMenu.Items, none)
+	} else {
+		recentMenu.Items = append(recentMenu.Items, fyne.NewMenuItemSeparator(),
+			fyne.NewMenuItem("Clear Recent", func() {
+				fyne.CurrentApp().Preferences().RemoveValue(recentKey)
+				showRecent()
+			}))
+	}
+	recentMenu.Refresh()
+}
+
+// addRecent puts path first in the recent files
+func addRecent(path string) {
+	prefs := fyne.CurrentApp().Preferences()
+	recent := slices.DeleteFunc(prefs.StringList(recentKey), func(p string) bool {
+		return p == path
+	})
+	recent = append([]string{path}, recent...)
+	prefs.SetStringList(recentKey, recent[:min(len(recent), maxRecent)])
+	showRecent()
+}
+
+// removeRecent drops a file (that can not be opened) from the recent files
+func removeRecent(path string) {
+	prefs := fyne.CurrentApp().Preferences()
+	prefs.SetStringList(recentKey, slices.DeleteFunc(prefs.StringList(recentKey), func(p string) bool {
+		return p == path
+	}))
+	showRecent()
+}
+
+// openFile opens path in a new tab, or selects the tab (of any window) it is open in
+func openFile(w fyne.Window, theme *textlist.MyTheme, path string) (*tab, error) {
+	for _, t := range allTabs() {
+		if t.path == path {
+			t.win.tabItems.Select(t.item)
+			t.win.window.RequestFocus()
+			return t, nil
+		}
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func(file *os.File) {
+		_ = file.Close()
+	}(file)
+	t, err := loadTab(w, theme, path, file)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", filepath.Base(path), err)
+	}
+	addRecent(path)
+	return t, nil
+}
+
+// saveSession keeps the tabs with a file, for the next start
+func saveSession() {
+	var session []sessionTab
+	for _, t := range allTabs() {
+		if t.path != "" {
+			session = append(session, sessionTab{
+				Path:   t.path,
+				Row:    t.editor.Row(),
+				Offset: t.editor.GetScrollOffset(),
+				Search: t.editor.SearchText(),
+			})
+		}
+	}
+	data, err := json.Marshal(session)
+	if err == nil {
+		fyne.CurrentApp().Preferences().SetString(sessionKey, string(data))
+	}
+}
+
+// restoreSession opens the tabs of the last session. A file that can not be opened is skipped.
+func restoreSession(w fyne.Window, theme *textlist.MyTheme) {
+	prefs := fyne.CurrentApp().Preferences()
+	if !prefs.Bool(restoreSessionKey) {
+		return
+	}
+	var session []sessionTab
+	if json.Unmarshal([]byte(prefs.String(sessionKey)), &session) != nil {
+		return
+	}
+	for _, s := range session {
+		t, err := openFile(w, theme, s.Path)
+		if err != nil {
+			continue
+		}
+		editor := t.editor
+		editor.SetSearchText(s.Search)
+		editor.MoveToRow(s.Row)
+		editor.ScrollToOffset(s.Offset)
+	}
+}
+
+// createSessionMenu chooses to reopen the tabs of the last session at startup
+func createSessionMenu() *fyne.MenuItem {
+	prefs := fyne.CurrentApp().Preferences()
+	item := fyne.NewMenuItem("Restore Session", nil)
+	item.Checked = prefs.Bool(restoreSessionKey)
+	item.Action = func() { // the preference, as another window may have changed it
+		item.Checked = !prefs.Bool(restoreSessionKey)
+		prefs.SetBool(restoreSessionKey, item.Checked)
+	}
+	return item
+}
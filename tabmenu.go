@@ -0,0 +1,156 @@
+package main
+
+import (
+	"edlin/textlist"
+	"edlin/textlist/buffer"
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+/*
+
+  File:    tabmenu.go
+  Author:  Bob Shofner
+
+  MIT License - https://opensource.org/license/mit/
+
+  This permission notice shall be included in all copies
+    or substantial portions of the Software.
+
+*/
+/*
+  Description: handle tab menu options.
+	DocTabs has no right click, so the menu is shown by a right click on
+	the file name above the lines of a tab, and as the Tab menu (for the
+	current tab).
+*/
+
+// createTabMenu acts on the current tab
+func createTabMenu(w fyne.Window, theme *textlist.MyTheme) *fyne.Menu {
+	menu := fyne.NewMenu("Tab", newTabMenu(w, theme, func() *tab {
+		return windowOf(w).current()
+	}).items...)
+	return menu
+}
+
+// showTabMenu shows the menu of a tab at a canvas position
+func showTabMenu(w fyne.Window, theme *textlist.MyTheme, t *tab, pos fyne.Position) {
+	tm := newTabMenu(w, theme, func() *tab {
+		return t
+	})
+	if t.path == "" {
+		for _, item := range tm.paths {
+			item.Disabled = true
+		}
+	}
+	tm.move.Disabled = len(t.win.tabs) == 1
+	widget.ShowPopUpMenuAtPosition(fyne.NewMenu("", tm.items...), w.Canvas(), pos)
+}
+
+// tabMenu is the items of the Tab menu, with those a tab may not allow
+type tabMenu struct {
+	items []*fyne.MenuItem
+	paths []*fyne.MenuItem // of the file of the tab
+	move  *fyne.MenuItem   // Move to New Window
+}
+
+// newTabMenu makes the items, acting on the tab of target
+func newTabMenu(w fyne.Window, theme *textlist.MyTheme, target func() *tab) (tm tabMenu) {
+	act := func(f func(t *tab)) func() {
+		return func() {
+			if t := target(); t != nil {
+				f(t)
+			}
+		}
+	}
+	tm.paths = []*fyne.MenuItem{
+		fyne.NewMenuItem("Copy Full Path", act(func(t *tab) {
+			if t.path != "" {
+				w.Clipboard().SetContent(t.path)
+			}
+		})),
+		fyne.NewMenuItem("Copy Relative Path", act(func(t *tab) {
+			if t.path != "" {
+				w.Clipboard().SetContent(relativePath(t.path))
+			}
+		})),
+		fyne.NewMenuItem("Open Containing Folder", act(func(t *tab) {
+			if t.path == "" {
+				return
+			}
+			folder := &url.URL{Scheme: "file", Path: filepath.ToSlash(filepath.Dir(t.path))}
+			if err := fyne.CurrentApp().OpenURL(folder); err != nil {
+				dialog.ShowError(err, w)
+			}
+		})),
+	}
+	tm.move = fyne.NewMenuItem("Move to New Window", act(moveToNewWindow))
+	tm.items = []*fyne.MenuItem{
+		fyne.NewMenuItem("Close Others", act(func(t *tab) {
+			closeTabs(w, slices.DeleteFunc(slices.Clone(t.win.tabs), func(o *tab) bool {
+				return o == t
+			}))
+		})),
+		fyne.NewMenuItem("Close to the Right", act(func(t *tab) {
+			closeTabs(w, slices.Clone(t.win.tabs[slices.Index(t.win.tabs, t)+1:]))
+		})),
+		fyne.NewMenuItem("Close Saved", act(func(t *tab) {
+			closeTabs(w, slices.DeleteFunc(slices.Clone(t.win.tabs), func(o *tab) bool {
+				return o.editor.Modified()
+			}))
+		})),
+		fyne.NewMenuItemSeparator(),
+	}
+	tm.items = append(tm.items, tm.paths...)
+	tm.items = append(tm.items,
+		fyne.NewMenuItemSeparator(),
+		fyne.NewMenuItem("Duplicate Tab", act(func(t *tab) {
+			duplicateTab(w, theme, t)
+		})),
+		tm.move,
+	)
+	return tm
+}
+
+// closeTabs closes the tabs, asking to save those with unsaved changes
+func closeTabs(w fyne.Window, closing []*tab) {
+	var unsaved []*tab
+	for _, t := range closing {
+		if t.editor.Modified() {
+			unsaved = append(unsaved, t)
+		}
+	}
+	confirmUnsaved(w, unsaved, func() {
+		for _, t := range closing {
+			closeTab(t.item)
+		}
+	})
+}
+
+// relativePath is path from the working folder, when it is inside it. Otherwise it is path.
+func relativePath(path string) string {
+	if wd, err := os.Getwd(); err == nil {
+		rel, err := filepath.Rel(wd, path)
+		if err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return rel
+		}
+	}
+	return path
+}
+
+// duplicateTab opens a copy of a tab, of the same file
+func duplicateTab(w fyne.Window, theme *textlist.MyTheme, t *tab) {
+	from := t.editor.Buffer()
+	b := buffer.NewBuffer(from.Lines(0, from.Len()-1)...)
+	b.SetFormat(from.Format())
+	b.SetModified(from.Modified())
+	duplicate := bufferTab(w, theme, t.path, t.name, b)
+	duplicate.editor.SetReadOnly(t.editor.ReadOnly())
+	duplicate.editor.MoveToRow(t.editor.Row())
+}
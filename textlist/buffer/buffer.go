@@ -0,0 +1,559 @@
+package buffer
+
+import (
+	"bufio"
+	"fmt"
+	"golang.org/x/text/cases"
+	"io"
+	"iter"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+/*
+
+  File:    buffer.go
+  Author:  Bob Shofner
+
+  MIT License - https://opensource.org/license/mit/
+
+  This permission notice shall be included in all copies
+    or substantial portions of the Software.
+
+*/
+/*
+  Description: Buffer is the headless text model of a TextList.
+	It holds the lines, the marked (highlighted) text and provides search.
+	The buffer package does not import fyne, so the Buffer, the EDLIN
+	commands and Script may be used by servers and tools.
+	The lines are stored in a pieceTable, so large files stay fast.
+
+	Lines are 0 based. Columns are rune (not byte) offsets, and
+	a column range col1..col2 is inclusive.
+*/
+
+// Match is a found (or marked) rune range on a line
+type Match struct {
+	Line int
+	Col1 int
+	Col2 int
+}
+
+// Buffer is an ordered list of text lines
+type Buffer struct {
+	table    *pieceTable
+	marks    map[int][]Match
+	format   Format
+	modified bool
+	changes  int
+
+	OnModified func(modified bool) // called when Modified changes
+	OnChange   func()              // called after each change to the lines
+
+	history
+}
+
+// NewBuffer creates a Buffer with the given lines
+func NewBuffer(lines ...string) *Buffer {
+	b := NewBufferBytes(nil)
+	b.table.append(append([]string(nil), lines...))
+	return b
+}
+
+// NewBufferString creates a Buffer from content (separated by \n)
+func NewBufferString(content string) *Buffer {
+	return NewBuffer(strings.Split(content, "\n")...)
+}
+
+// NewBufferBytes creates a Buffer on the content of a file, detecting its Format.
+// Content that is not UTF-8 (and has no BOM) is read as DefaultLegacyEncoding.
+func NewBufferBytes(data []byte) *Buffer {
+	b, _ := NewBufferEncoding(data, DefaultLegacyEncoding)
+	return b
+}
+
+// NewBufferEncoding creates a Buffer on the content of a file, detecting its Format.
+// Content that is not UTF-8 (and has no BOM) is read as the legacy encoding.
+// The Buffer may keep data, which must not be changed afterward.
+func NewBufferEncoding(data []byte, legacy string) (*Buffer, error) {
+	text, name, bom, err := decode(data, legacy)
+	if err != nil {
+		return nil, err
+	}
+	b := &Buffer{
+		marks:   make(map[int][]Match),
+		history: history{depth: DefaultUndoDepth},
+	}
+	if len(text) == 0 {
+		b.format = DefaultFormat
+	} else {
+		b.format = DetectFormat(text)
+	}
+	b.format.Encoding = name
+	b.format.BOM = bom
+	sep := byte('\n')
+	if b.format.Ending == CR {
+		sep = '\r'
+	}
+	b.table = newPieceTable(text, sep)
+	return b, nil
+}
+
+// ReadBuffer creates a Buffer from all of r. legacy is the encoding for content that is not UTF-8.
+func ReadBuffer(r io.Reader, legacy string) (*Buffer, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	return NewBufferEncoding(data, legacy)
+}
+
+// Snapshot returns a copy of the lines and Format that later edits do not change,
+// to be read on another goroutine. The lines themselves are shared, not copied.
+func (b *Buffer) Snapshot() *Buffer {
+	return &Buffer{
+		table:    b.table.snapshot(),
+		marks:    make(map[int][]Match),
+		format:   b.format,
+		modified: b.modified,
+		changes:  b.changes,
+		history:  history{depth: b.depth},
+	}
+}
+
+// Len returns the number of lines
+func (b *Buffer) Len() int {
+	return b.table.length
+}
+
+// Line returns line n, or "" if n is past the end
+func (b *Buffer) Line(n int) string {
+	if n < 0 || n >= b.table.length {
+		return ""
+	}
+	return b.table.line(n)
+}
+
+// Lines returns a copy of the lines start..end (inclusive)
+func (b *Buffer) Lines(start, end int) (lines []string) {
+	b.table.each(max(start, 0), func(n int, line string) bool {
+		if n > end {
+			return false
+		}
+		lines = append(lines, line)
+		return true
+	})
+	return
+}
+
+// All provides a GO 1.23 range operator over the line numbers and lines
+func (b *Buffer) All() iter.Seq2[int, string] {
+	return func(yield func(int, string) bool) {
+		b.table.each(0, yield)
+	}
+}
+
+// String returns all the lines separated by \n
+func (b *Buffer) String() string {
+	var builder strings.Builder
+	for n, line := range b.All() {
+		if n > 0 {
+			builder.WriteString("\n")
+		}
+		builder.WriteString(line)
+	}
+	return builder.String()
+}
+
+// WriteQuoted writes each line quoted (as Go does) on a line of its own, so a
+// line holding \n (as in a CR file) reads back as one line with ReadQuoted
+func (b *Buffer) WriteQuoted(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	for _, line := range b.All() {
+		bw.WriteString(strconv.Quote(line))
+		bw.WriteByte('\n')
+	}
+	return bw.Flush()
+}
+
+// ReadQuoted reads the lines written by WriteQuoted
+func ReadQuoted(r io.Reader) (lines []string, err error) {
+	br := bufio.NewReader(r)
+	for {
+		quoted, err := br.ReadString('\n')
+		if err == io.EOF && quoted == "" {
+			return lines, nil
+		} else if err != nil && err != io.EOF {
+			return nil, err
+		}
+		line, err := strconv.Unquote(strings.TrimSuffix(quoted, "\n"))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", len(lines)+1, err)
+		}
+		lines = append(lines, line)
+	}
+}
+
+// Append adds lines to the end, without undo history (as when loading)
+func (b *Buffer) Append(lines ...string) {
+	b.table.append(lines)
+}
+
+// Insert inserts lines before line n
+func (b *Buffer) Insert(n int, lines ...string) {
+	n = min(max(n, 0), b.table.length)
+	b.apply(edit{line: n, inserted: lines})
+}
+
+// InsertString inserts content (separated by \n) before line n
+func (b *Buffer) InsertString(n int, content string) {
+	b.Insert(n, strings.Split(content, "\n")...)
+}
+
+// Delete removes the lines start..end (inclusive) and returns them
+func (b *Buffer) Delete(start, end int) []string {
+	end = min(end, b.table.length-1)
+	if start < 0 || start > end {
+		return nil
+	}
+	deleted := b.Lines(start, end)
+	b.apply(edit{line: start, deleted: deleted})
+	return deleted
+}
+
+// Replace replaces line n. A line that does not exist is ignored.
+func (b *Buffer) Replace(n int, line string) {
+	if n < 0 || n >= b.table.length {
+		return
+	}
+	b.apply(edit{line: n, deleted: []string{b.Line(n)}, inserted: []string{line}})
+}
+
+// Replacement is new text for the cells of a Match
+type Replacement struct {
+	Match
+	Text string
+}
+
+// ReplaceAll makes the replacements as one undo step, and returns the number of lines changed.
+// The replacements of a line must not overlap. Those of a line that does not exist are ignored.
+func (b *Buffer) ReplaceAll(rs []Replacement) (lines int) {
+	rs = slices.Clone(rs)
+	slices.SortFunc(rs, func(x, y Replacement) int {
+		if x.Line != y.Line {
+			return x.Line - y.Line
+		}
+		return x.Col1 - y.Col1
+	})
+	b.Begin()
+	defer b.End()
+	for i := 0; i < len(rs); {
+		n := rs[i].Line
+		if n < 0 || n >= b.table.length {
+			for i < len(rs) && rs[i].Line == n {
+				i++
+			}
+			continue
+		}
+		lines++
+		runes := []rune(b.Line(n))
+		var line strings.Builder
+		at := 0
+		for ; i < len(rs) && rs[i].Line == n; i++ {
+			col1 := min(max(rs[i].Col1, at), len(runes))
+			line.WriteString(string(runes[at:col1]))
+			line.WriteString(rs[i].Text)
+			at = min(max(rs[i].Col2+1, col1), len(runes))
+		}
+		line.WriteString(string(runes[at:]))
+		b.Replace(n, line.String())
+	}
+	return lines
+}
+
+// ReplaceRange replaces the lines start..end (inclusive) with lines.
+// end is clamped to the last line, and a start that is not a line is ignored.
+func (b *Buffer) ReplaceRange(start, end int, lines ...string) {
+	end = min(end, b.table.length-1)
+	if start < 0 || start > end {
+		return
+	}
+	b.apply(edit{line: start, deleted: b.Lines(start, end), inserted: lines})
+}
+
+// replaceLines removes n lines at line, inserts lines, and adjusts the marks
+func (b *Buffer) replaceLines(line, n int, lines []string) {
+	b.table.replace(line, n, lines)
+	b.changed()
+	for i := line; i < line+n; i++ {
+		delete(b.marks, i)
+	}
+	b.shiftMarks(line+n, len(lines)-n)
+}
+
+// Splice replaces the runes col1..col2 (inclusive) of line n with text.
+// Marks following the replaced runes move with the text. Columns past the line are clamped,
+// and a line that does not exist is ignored.
+func (b *Buffer) Splice(n, col1, col2 int, text string) {
+	if n < 0 || n >= b.table.length {
+		return
+	}
+	old := b.Line(n)
+	runes := []rune(old)
+	col1 = min(max(col1, 0), len(runes))
+	col2 = min(max(col2, col1-1), len(runes)-1)
+	line := string(runes[:col1]) + text + string(runes[col2+1:])
+	b.record(edit{line: n, deleted: []string{old}, inserted: []string{line}})
+	b.table.replace(n, 1, []string{line})
+	b.changed()
+
+	delta := len([]rune(text)) - (col2 - col1 + 1)
+	var marks []Match
+	for _, m := range b.marks[n] {
+		switch {
+		case m.Col2 < col1:
+		case m.Col1 > col2:
+			m.Col1 += delta
+			m.Col2 += delta
+		default:
+			continue
+		}
+		marks = append(marks, m)
+	}
+	b.setMarks(n, marks)
+}
+
+// Modified reports whether the Buffer changed since it was read (or last saved)
+func (b *Buffer) Modified() bool {
+	return b.modified
+}
+
+// Changes counts the changes made, so a copy (such as a journal) can tell it is out of date
+func (b *Buffer) Changes() int {
+	return b.changes
+}
+
+func (b *Buffer) changed() {
+	b.changes++
+	b.SetModified(true)
+	if b.OnChange != nil {
+		b.OnChange()
+	}
+}
+
+// SetModified sets the modified flag. A save clears it.
+func (b *Buffer) SetModified(modified bool) {
+	if b.modified == modified {
+		return
+	}
+	b.modified = modified
+	if b.OnModified != nil {
+		b.OnModified(modified)
+	}
+}
+
+// Mark marks the runes col1..col2 (inclusive) of line n
+func (b *Buffer) Mark(n, col1, col2 int) {
+	if col2 < col1 {
+		return
+	}
+	b.marks[n] = append(b.marks[n], Match{Line: n, Col1: col1, Col2: col2})
+}
+
+// MarkLines marks all of the lines start..end (inclusive)
+func (b *Buffer) MarkLines(start, end int) {
+	for n := start; n <= end; n++ {
+		b.marks[n] = []Match{{Line: n, Col1: 0, Col2: -1}}
+	}
+}
+
+// ClearMarks removes all the marks
+func (b *Buffer) ClearMarks() {
+	clear(b.marks)
+}
+
+// IsMarked reports whether the rune at col of line n is marked
+func (b *Buffer) IsMarked(n, col int) bool {
+	for _, m := range b.marks[n] {
+		if m.Col2 < 0 || (col >= m.Col1 && col <= m.Col2) {
+			return true
+		}
+	}
+	return false
+}
+
+// LineMarked reports whether all of line n is marked
+func (b *Buffer) LineMarked(n int) bool {
+	for _, m := range b.marks[n] {
+		if m.Col2 < 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// Marks returns the marks, in line order
+func (b *Buffer) Marks() (marks []Match) {
+	for _, m := range b.marks {
+		marks = append(marks, m...)
+	}
+	sort.Slice(marks, func(i, j int) bool {
+		if marks[i].Line == marks[j].Line {
+			return marks[i].Col1 < marks[j].Col1
+		}
+		return marks[i].Line < marks[j].Line
+	})
+	return
+}
+
+func (b *Buffer) setMarks(n int, marks []Match) {
+	if len(marks) == 0 {
+		delete(b.marks, n)
+		return
+	}
+	b.marks[n] = marks
+}
+
+// shiftMarks moves the marks of lines from n onward by delta lines
+func (b *Buffer) shiftMarks(n, delta int) {
+	if delta == 0 || len(b.marks) == 0 {
+		return
+	}
+	marks := make(map[int][]Match, len(b.marks))
+	for line, ms := range b.marks {
+		if line >= n {
+			line += delta
+			for i := range ms {
+				ms[i].Line = line
+			}
+		}
+		marks[line] = ms
+	}
+	b.marks = marks
+}
+
+// Search finds each (non-overlapping) match of find in each line, starting at line start
+// and wrapping around to the lines before it.
+func (b *Buffer) Search(start int, find string, opt SearchOptions) (matches []Match) {
+	folder := cases.Fold()
+	match, _ := foldRunes([]rune(find), opt, folder)
+	if len(match) == 0 || b.table.length == 0 {
+		return
+	}
+	return b.searchLines(start, func(line string) []Match {
+		runes := []rune(line)
+		folded, cols := foldRunes(runes, opt, folder)
+		return findCellMatches(runes, folded, cols, match, opt)
+	})
+}
+
+// SearchRegexp finds each match of re in each line, as Search does.
+// A match of no text (such as ^) is skipped, as it has no cells to mark.
+// Of opt, only WholeWord is used: re chooses its own case rules.
+func (b *Buffer) SearchRegexp(start int, re *regexp.Regexp, opt SearchOptions) []Match {
+	if b.table.length == 0 {
+		return nil
+	}
+	return b.searchLines(start, func(line string) (ms []Match) {
+		col, at := 0, 0 // the rune column of byte at
+		for _, loc := range re.FindAllStringIndex(line, -1) {
+			if loc[0] == loc[1] {
+				continue
+			}
+			col += utf8.RuneCountInString(line[at:loc[0]])
+			n := utf8.RuneCountInString(line[loc[0]:loc[1]])
+			m := Match{Col1: col, Col2: col + n - 1}
+			if !opt.WholeWord || isWholeWord([]rune(line), m) {
+				ms = append(ms, m)
+			}
+			col, at = col+n, loc[1]
+		}
+		return ms
+	})
+}
+
+// searchLines finds the matches in each line, from line start, wrapping around to the lines before it
+func (b *Buffer) searchLines(start int, find func(line string) []Match) (matches []Match) {
+	start = min(max(start, 0), b.table.length-1)
+	var wrapped []Match
+	b.table.each(0, func(n int, line string) bool {
+		ms := find(line)
+		for i := range ms {
+			ms[i].Line = n
+		}
+		if n < start {
+			wrapped = append(wrapped, ms...)
+		} else {
+			matches = append(matches, ms...)
+		}
+		return true
+	})
+	return append(matches, wrapped...)
+}
+
+// Submatch is the text of a match of a regexp, with the byte offsets in Text of its groups
+type Submatch struct {
+	Text   string
+	Groups []int
+}
+
+// Expand returns template with $1 or ${name} replaced by the groups of the Submatch of re
+func (s Submatch) Expand(re *regexp.Regexp, template string) string {
+	return string(re.ExpandString(nil, template, s.Text, s.Groups))
+}
+
+// Submatches returns the Submatch of each of ms, the matches of re (from SearchRegexp),
+// to be expanded after the lines change. One that re no longer matches is empty (no Groups).
+func (b *Buffer) Submatches(re *regexp.Regexp, ms []Match) []Submatch {
+	subs := make([]Submatch, len(ms))
+	line, n := "", -1
+	var found map[int][]int // the groups of each match of line n, by its start column
+	for i, m := range ms {
+		if m.Line != n {
+			line, n = b.Line(m.Line), m.Line
+			found = make(map[int][]int)
+			col, at := 0, 0 // the rune column of byte at
+			for _, sub := range re.FindAllStringSubmatchIndex(line, -1) {
+				col += utf8.RuneCountInString(line[at:sub[0]])
+				at = sub[0]
+				found[col] = sub
+			}
+		}
+		if sub := found[m.Col1]; sub != nil && sub[0] < sub[1] {
+			groups := make([]int, len(sub))
+			for g, ix := range sub {
+				groups[g] = max(ix-sub[0], -1)
+			}
+			subs[i] = Submatch{Text: line[sub[0]:sub[1]], Groups: groups}
+		}
+	}
+	return subs
+}
+
+// findCellMatches finds the non-overlapping matches in the folded runes of a line, trying each
+// start (so "aab" is found in "aaab"). cols is the column of runes each folded rune came from.
+func findCellMatches(runes, folded []rune, cols []int, match []rune, opt SearchOptions) (ms []Match) {
+	for fx := 0; fx+len(match) <= len(folded); fx++ {
+		end := fx + len(match) // after the match
+		switch {
+		case !slices.Equal(folded[fx:end], match):
+			continue
+		case fx > 0 && cols[fx-1] == cols[fx], end < len(folded) && cols[end] == cols[end-1]:
+			continue // part of a rune (the s of ß)
+		case opt.Normalize && end < len(folded) && unicode.Is(unicode.Mn, folded[end]):
+			continue // e is not é
+		}
+		m := Match{Col1: cols[fx], Col2: cols[end-1]}
+		if opt.WholeWord && !isWholeWord(runes, m) {
+			continue
+		}
+		ms = append(ms, m)
+		fx = end - 1
+	}
+	return ms
+}
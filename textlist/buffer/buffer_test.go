@@ -0,0 +1,247 @@
+package buffer
+
+import (
+	"bytes"
+	"regexp"
+	"slices"
+	"testing"
+)
+
+/*
+
+  File:    buffer_test.go
+  Author:  Bob Shofner
+
+  MIT License - https://opensource.org/license/mit/
+
+  This permission notice shall be included in all copies
+    or substantial portions of the Software.
+
+*/
+/*
+  Description: tests of the Buffer edits and marks.
+*/
+
+func TestBufferEdit(t *testing.T) {
+	tests := []struct {
+		name string
+		edit func(b *Buffer)
+		want []string
+	}{
+		{"insert", func(b *Buffer) { b.Insert(1, "x", "y") }, []string{"a", "x", "y", "b", "c"}},
+		{"insert past end", func(b *Buffer) { b.Insert(9, "x") }, []string{"a", "b", "c", "x"}},
+		{"insert string", func(b *Buffer) { b.InsertString(0, "x\ny") }, []string{"x", "y", "a", "b", "c"}},
+		{"delete", func(b *Buffer) { b.Delete(0, 1) }, []string{"c"}},
+		{"delete past end", func(b *Buffer) { b.Delete(1, 9) }, []string{"a"}},
+		{"replace", func(b *Buffer) { b.Replace(1, "x") }, []string{"a", "x", "c"}},
+		{"replace range", func(b *Buffer) { b.ReplaceRange(0, 1, "x") }, []string{"x", "c"}},
+		{"splice", func(b *Buffer) { b.Splice(1, 0, 0, "xy") }, []string{"a", "xy", "c"}},
+		{"splice insert", func(b *Buffer) { b.Splice(1, 1, 0, "x") }, []string{"a", "bx", "c"}},
+		{"splice clamped", func(b *Buffer) { b.Splice(2, 5, 9, "x") }, []string{"a", "b", "cx"}},
+		{"splice no line", func(b *Buffer) { b.Splice(5, 0, 0, "x") }, []string{"a", "b", "c"}},
+		{"replace before start", func(b *Buffer) { b.Replace(-1, "x") }, []string{"a", "b", "c"}},
+		{"replace past end", func(b *Buffer) { b.Replace(3, "x") }, []string{"a", "b", "c"}},
+		{"replace range clamped", func(b *Buffer) { b.ReplaceRange(1, 9, "x") }, []string{"a", "x"}},
+		{"replace range before start", func(b *Buffer) { b.ReplaceRange(-1, 0, "x") }, []string{"a", "b", "c"}},
+		{"replace range past end", func(b *Buffer) { b.ReplaceRange(5, 6, "x") }, []string{"a", "b", "c"}},
+		{"replace all no line", func(b *Buffer) {
+			b.ReplaceAll([]Replacement{{Match{-1, 0, 0}, "x"}, {Match{5, 0, 0}, "x"}, {Match{1, 0, 0}, "x"}})
+		}, []string{"a", "x", "c"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := NewBuffer("a", "b", "c")
+			tt.edit(b)
+			if lines := b.Lines(0, b.Len()-1); !slices.Equal(lines, tt.want) {
+				t.Errorf("lines %q, want %q", lines, tt.want)
+			}
+			if b.Modified() != !slices.Equal(tt.want, []string{"a", "b", "c"}) {
+				t.Errorf("modified %v", b.Modified())
+			}
+		})
+	}
+}
+
+func TestBufferUndoPastEnd(t *testing.T) {
+	b := NewBuffer("a", "b")
+	b.Replace(5, "x")
+	b.ReplaceRange(5, 5, "x")
+	b.Undo()
+	if got := b.Lines(0, b.Len()-1); !slices.Equal(got, []string{"a", "b"}) || b.CanUndo() {
+		t.Errorf("lines %q, undo %v", got, b.CanUndo())
+	}
+}
+
+func TestBufferMarks(t *testing.T) {
+	b := NewBuffer("abc", "def")
+	b.Mark(1, 1, 2)
+	b.Insert(0, "new")
+	if !b.IsMarked(2, 1) || b.IsMarked(1, 1) {
+		t.Errorf("marks %v, want them moved to line 2", b.Marks())
+	}
+	b.Splice(2, 0, 0, "xx") // before the mark
+	if got := b.Marks(); !slices.Equal(got, []Match{{2, 2, 3}}) {
+		t.Errorf("marks %v after splice", got)
+	}
+	b.Delete(2, 2)
+	if len(b.Marks()) != 0 {
+		t.Errorf("marks %v on a deleted line", b.Marks())
+	}
+}
+
+func TestBufferQuoted(t *testing.T) {
+	cr := NewBufferBytes([]byte("a\nb\rc\r"))
+	if cr.Format().Ending != CR || cr.Line(0) != "a\nb" {
+		t.Fatalf("format %v, line %q, want CR", cr.Format(), cr.Line(0))
+	}
+	tests := []struct {
+		name string
+		b    *Buffer
+	}{
+		{"lines", NewBuffer("a", "", `"quoted" \ tab\t`, "é")},
+		{"new line in a CR file", cr},
+		{"one empty line", NewBuffer("")},
+		{"empty", NewBuffer()},
+		{"not UTF-8", NewBuffer("a\xff")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var data bytes.Buffer
+			if err := tt.b.WriteQuoted(&data); err != nil {
+				t.Fatal(err)
+			}
+			got, err := ReadQuoted(&data)
+			if want := tt.b.Lines(0, tt.b.Len()-1); err != nil || !slices.Equal(got, want) {
+				t.Errorf("ReadQuoted = %q, %v, want %q", got, err, want)
+			}
+		})
+	}
+	if _, err := ReadQuoted(bytes.NewBufferString("\"a\"\nb\n")); err == nil {
+		t.Error("ReadQuoted of a line not quoted, want an error")
+	}
+}
+
+func TestBufferSnapshot(t *testing.T) {
+	b := NewBufferBytes([]byte("a\r\nb\r\nc\r\n"))
+	b.Insert(3, "d")
+	snap := b.Snapshot()
+	b.Replace(0, "x")
+	b.Insert(4, "e") // appends to the add lines the snapshot shares
+	b.Delete(1, 1)
+	b.SetFormat(Format{Encoding: "UTF-8", Ending: LF})
+	if got := snap.Lines(0, snap.Len()-1); !slices.Equal(got, []string{"a", "b", "c", "d"}) {
+		t.Errorf("snapshot %q after edits", got)
+	}
+	if snap.Format().Ending != CRLF || !snap.Modified() || snap.CanUndo() {
+		t.Errorf("snapshot format %v, modified %v, undo %v", snap.Format(), snap.Modified(), snap.CanUndo())
+	}
+	b.Undo()
+	b.Undo()
+	b.Undo()
+	if got := b.Lines(0, b.Len()-1); !slices.Equal(got, []string{"a", "b", "c", "d"}) {
+		t.Errorf("buffer %q after undo", got)
+	}
+}
+
+func TestBufferSearchRegexp(t *testing.T) {
+	b := NewBuffer("é1 x22", "", "3")
+	tests := []struct {
+		name string
+		re   string
+		opt  SearchOptions
+		want []Match
+	}{
+		{"columns are runes", `\d+`, SearchOptions{}, []Match{{0, 1, 1}, {0, 4, 5}, {2, 0, 0}}},
+		{"empty matches skipped", `\d|^`, SearchOptions{}, []Match{{0, 1, 1}, {0, 4, 4}, {0, 5, 5}, {2, 0, 0}}},
+		{"whole word", `\d+`, SearchOptions{WholeWord: true}, []Match{{2, 0, 0}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := b.SearchRegexp(0, regexp.MustCompile(tt.re), tt.opt); !slices.Equal(got, tt.want) {
+				t.Errorf("SearchRegexp = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBufferSubmatches(t *testing.T) {
+	re := regexp.MustCompile(`(\w)(?P<second>\w)`)
+	b := NewBuffer("abcd", "éf")
+	ms := b.SearchRegexp(0, re, SearchOptions{})
+	if !slices.Equal(ms, []Match{{0, 0, 1}, {0, 2, 3}}) {
+		t.Fatalf("SearchRegexp = %v", ms) // \w is ASCII: é is not a word rune
+	}
+	subs := b.Submatches(re, ms)
+	for i, want := range []string{"bax", "dcx"} {
+		if got := subs[i].Expand(re, "${second}${1}x"); got != want {
+			t.Errorf("Expand of %q = %q, want %q", subs[i].Text, got, want)
+		}
+	}
+	// replace as the search view does: the line changes before the second is expanded
+	b.Splice(0, 0, 1, subs[0].Expand(re, "${2}${1}x"))
+	b.Splice(0, 3, 4, subs[1].Expand(re, "${2}${1}x"))
+	if got := b.Line(0); got != "baxdcx" {
+		t.Errorf("line %q, want %q", got, "baxdcx")
+	}
+	if gone := b.Submatches(re, []Match{{0, 1, 2}}); gone[0].Groups != nil {
+		t.Errorf("Submatch %v where re no longer matches, want none", gone[0])
+	}
+}
+
+func TestBufferSearch(t *testing.T) {
+	tests := []struct {
+		name  string
+		lines []string
+		start int
+		find  string
+		opt   SearchOptions
+		want  []Match
+	}{
+		{"every match", []string{"go go", "x", "go"}, 0, "go", SearchOptions{}, []Match{{0, 0, 1}, {0, 3, 4}, {2, 0, 1}}},
+		{"overlapping start", []string{"aaab"}, 0, "aab", SearchOptions{}, []Match{{0, 1, 3}}},
+		{"non overlapping", []string{"aaaa"}, 0, "aa", SearchOptions{}, []Match{{0, 0, 1}, {0, 2, 3}}},
+		{"runes", []string{"éaé"}, 0, "aé", SearchOptions{}, []Match{{0, 1, 2}}},
+		{"case", []string{"Go go GO"}, 0, "go", SearchOptions{}, []Match{{0, 3, 4}}},
+		{"ignore case", []string{"Go go GO"}, 0, "go", SearchOptions{IgnoreCase: true},
+			[]Match{{0, 0, 1}, {0, 3, 4}, {0, 6, 7}}},
+		{"fold ß", []string{"Straße"}, 0, "STRASSE", SearchOptions{IgnoreCase: true}, []Match{{0, 0, 5}}},
+		{"part of ß", []string{"Straße"}, 0, "s", SearchOptions{IgnoreCase: true}, []Match{{0, 0, 0}}},
+		{"fold σ", []string{"ΟΔΟΣ οδος"}, 0, "οδοσ", SearchOptions{IgnoreCase: true}, []Match{{0, 0, 3}, {0, 5, 8}}},
+		{"whole word", []string{"go gopher go_ go"}, 0, "go", SearchOptions{WholeWord: true},
+			[]Match{{0, 0, 1}, {0, 14, 15}}},
+		{"NFD in NFC", []string{"caf\u00e9"}, 0, "cafe\u0301", SearchOptions{Normalize: true}, []Match{{0, 0, 3}}},
+		{"NFC in NFD", []string{"cafe\u0301!"}, 0, "caf\u00e9", SearchOptions{Normalize: true}, []Match{{0, 0, 4}}},
+		{"e is not é", []string{"cafe\u0301"}, 0, "cafe", SearchOptions{Normalize: true}, nil},
+		{"not normalized", []string{"caf\u00e9"}, 0, "cafe\u0301", SearchOptions{}, nil},
+		{"fold and normalize", []string{"CAF\u00c9"}, 0, "cafe\u0301", SearchOptions{IgnoreCase: true, Normalize: true},
+			[]Match{{0, 0, 3}}},
+		{"wrap", []string{"x", "y", "x"}, 1, "x", SearchOptions{}, []Match{{2, 0, 0}, {0, 0, 0}}},
+		{"empty find", []string{"x"}, 0, "", SearchOptions{}, nil},
+		{"empty buffer", nil, 0, "x", SearchOptions{}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := NewBuffer(tt.lines...).Search(tt.start, tt.find, tt.opt)
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("Search %q = %v, want %v", tt.find, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBufferReplaceAll(t *testing.T) {
+	b := NewBuffer("a-a", "b", "a")
+	lines := b.ReplaceAll([]Replacement{
+		{Match{2, 0, 0}, "z"},
+		{Match{0, 2, 2}, "xy"},
+		{Match{0, 0, 0}, ""},
+		{Match{5, 0, 0}, "gone"}, // no such line
+	})
+	if lines != 2 || b.String() != "-xy\nb\nz" {
+		t.Errorf("%d lines, %q", lines, b.String())
+	}
+	b.Undo()
+	if b.String() != "a-a\nb\na" || b.CanUndo() {
+		t.Errorf("undo %q, want one step", b.String())
+	}
+}
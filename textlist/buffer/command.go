@@ -0,0 +1,321 @@
+package buffer
+
+import (
+	"errors"
+	"os"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+/*
+
+  File:    command.go
+  Author:  Bob Shofner
+
+  MIT License - https://opensource.org/license/mit/
+
+  This permission notice shall be included in all copies
+    or substantial portions of the Software.
+
+*/
+/*
+  Description: command parses and runs the classic DOS EDLIN line commands.
+	The editing is done through a LineEditor, a Buffer for a Script
+	(headless) or the rows of a TextList (GUI), so both behave the same.
+
+	[line]                      edit line
+	[line][,line]L              list lines
+	[line][,line]P              page lines
+	[line]I                     insert before line
+	[line][,line]D              delete lines
+	[line],[line],line[,count]C copy lines before line
+	[line],[line],lineM         move lines before line
+	[line]Tfilename             transfer (merge) a file before line
+	[line][,line][?]Sstring     search
+	[line][,line][?]Rold^Znew   replace
+
+	A line is a number, '.' (current line), '#' (after the last line),
+	'$' (the last line) or +n / -n relative to the current line.
+*/
+
+// ErrEntry is the EDLIN answer to a command it can not run
+var ErrEntry = errors.New("Entry error")
+
+// Command is a parsed EDLIN command. Lines are 1 based; 0 is omitted.
+type Command struct {
+	Params []int
+	Query  bool
+	Op     rune
+	Text   string
+}
+
+// Param returns the n'th line parameter, or def if it was omitted
+func (c Command) Param(n, def int) int {
+	if n < len(c.Params) && c.Params[n] > 0 {
+		return c.Params[n]
+	}
+	return def
+}
+
+// ParseCommand splits an EDLIN command into line parameters, option and text
+func ParseCommand(str string, current, count int) (cmd Command, err error) {
+	str = strings.TrimLeft(str, " \t")
+	ix := strings.IndexFunc(str, func(r rune) bool {
+		return unicode.IsLetter(r) || r == '?'
+	})
+	params := str
+	if ix >= 0 {
+		params = str[:ix]
+		rest := str[ix:]
+		if rest[0] == '?' {
+			cmd.Query = true
+			rest = rest[1:]
+		}
+		r, size := utf8.DecodeRuneInString(rest)
+		if size == 0 || !unicode.IsLetter(r) {
+			return cmd, ErrEntry
+		}
+		cmd.Op = unicode.ToUpper(r)
+		cmd.Text = rest[size:]
+	}
+	if strings.TrimSpace(params) == "" {
+		return
+	}
+	for _, tok := range strings.Split(params, ",") {
+		line, err := parseLine(tok, current, count)
+		if err != nil {
+			return cmd, err
+		}
+		cmd.Params = append(cmd.Params, line)
+	}
+	return
+}
+
+// parseLine resolves a line token to a 1 based line number (0 if empty)
+func parseLine(tok string, current, count int) (int, error) {
+	tok = strings.TrimSpace(tok)
+	if tok == "" {
+		return 0, nil
+	}
+	line := current
+	switch c := tok[0]; {
+	case c == '.':
+		tok = tok[1:]
+	case c == '#':
+		line = count + 1
+		tok = tok[1:]
+	case c == '$':
+		line = max(count, 1)
+		tok = tok[1:]
+	case c >= '0' && c <= '9':
+		n := strings.IndexFunc(tok, func(r rune) bool { return r < '0' || r > '9' })
+		if n < 0 {
+			n = len(tok)
+		}
+		line, _ = strconv.Atoi(tok[:n])
+		tok = tok[n:]
+	}
+	if tok != "" {
+		if tok[0] != '+' && tok[0] != '-' {
+			return 0, ErrEntry
+		}
+		n, err := strconv.Atoi(strings.TrimSpace(tok[1:]))
+		if err != nil {
+			return 0, ErrEntry
+		}
+		if tok[0] == '-' {
+			n = -n
+		}
+		line += n
+	}
+	if line < 1 {
+		return 0, ErrEntry
+	}
+	return min(line, count+1), nil
+}
+
+// SplitReplace separates the R command text at ^Z (Ctrl+Z, or typed as "^Z")
+func SplitReplace(text string) (find, repl string) {
+	for _, sep := range []string{"\x1a", "^Z", "^z"} {
+		if ix := strings.Index(text, sep); ix >= 0 {
+			return text[:ix], text[ix+len(sep):]
+		}
+	}
+	return text, ""
+}
+
+// LineEditor is what the commands edit through: a Buffer, or the rows of a TextList.
+// Lines are 0 based, as they are for a Buffer.
+type LineEditor interface {
+	Len() int
+	Line(n int) string
+	InsertString(n int, content string)
+	Delete(start, end int) []string
+	Splice(n, col1, col2 int, text string)
+}
+
+// EditLine returns the rowId to edit
+func EditLine(cmd Command, current, count int) (int, error) {
+	line := cmd.Param(0, min(current+1, count))
+	if len(cmd.Params) > 1 || line < 1 || line > count {
+		return 0, ErrEntry
+	}
+	return line - 1, nil
+}
+
+// ListLines returns the rows shown by L. There are none (end < start) when the buffer is empty.
+func ListLines(cmd Command, current, count int) (start, end int, err error) {
+	if count == 0 {
+		return 0, -1, nil
+	}
+	first := cmd.Param(0, max(current-11, 1))
+	last := cmd.Param(1, first+22)
+	if len(cmd.Params) > 1 && cmd.Params[0] == 0 {
+		first = max(last-22, 1)
+	}
+	last = min(last, count)
+	if first > last {
+		return 0, 0, ErrEntry
+	}
+	return first - 1, last - 1, nil
+}
+
+// PageLines returns the rows shown by P. The last becomes the current row.
+// There are none (end < start) when the buffer is empty.
+func PageLines(cmd Command, current, count int) (start, end int, err error) {
+	if count == 0 {
+		return 0, -1, nil
+	}
+	first := cmd.Param(0, min(current+1, count))
+	if current == 1 && len(cmd.Params) == 0 {
+		first = 1
+	}
+	last := min(cmd.Param(1, first+22), count)
+	if first > last {
+		return 0, 0, ErrEntry
+	}
+	return first - 1, last - 1, nil
+}
+
+// InsertLine returns the rowId that I inserts before
+func InsertLine(cmd Command, current int) (int, error) {
+	if len(cmd.Params) > 1 {
+		return 0, ErrEntry
+	}
+	return cmd.Param(0, current) - 1, nil
+}
+
+// SearchRange returns the rows searched by S and R
+func SearchRange(cmd Command, current, count int) (start, end int) {
+	first := cmd.Param(0, min(current+1, count))
+	last := min(cmd.Param(1, count), count)
+	return first - 1, last - 1
+}
+
+// DeleteCommand runs D, returning the new current rowId
+func DeleteCommand(e LineEditor, cmd Command, current int) (int, error) {
+	start := cmd.Param(0, current)
+	end := cmd.Param(1, start)
+	if start > end || end > e.Len() {
+		return 0, ErrEntry
+	}
+	e.Delete(start-1, end-1)
+	return start - 1, nil
+}
+
+// CopyMoveCommand runs C and M, returning the new current rowId
+func CopyMoveCommand(e LineEditor, cmd Command, current int) (int, error) {
+	if len(cmd.Params) < 3 || cmd.Params[2] == 0 {
+		return 0, ErrEntry
+	}
+	start := cmd.Param(0, current)
+	end := cmd.Param(1, start)
+	target := cmd.Params[2]
+	times := cmd.Param(3, 1)
+	if start > end || end > e.Len() || (cmd.Op == 'M' && len(cmd.Params) > 3) {
+		return 0, ErrEntry
+	}
+	if target > start && target <= end {
+		return 0, ErrEntry
+	}
+	start, end, target = start-1, end-1, target-1
+	content := strings.Join(lineStrings(e, start, end), "\n")
+	if cmd.Op == 'C' {
+		for i := 0; i < times; i++ {
+			e.InsertString(target, content)
+		}
+		return target, nil
+	}
+	n := end - start + 1
+	if target > end {
+		e.InsertString(target, content)
+		e.Delete(start, end)
+		return target - n, nil
+	}
+	e.Delete(start, end)
+	e.InsertString(target, content)
+	return target, nil
+}
+
+// TransferCommand runs T, returning the new current rowId
+func TransferCommand(e LineEditor, cmd Command, current int) (int, error) {
+	name := strings.TrimSpace(cmd.Text)
+	if len(cmd.Params) > 1 || name == "" {
+		return 0, ErrEntry
+	}
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return 0, err
+	}
+	rowId := cmd.Param(0, current) - 1
+	content := strings.ReplaceAll(string(data), "\r\n", "\n")
+	content = strings.TrimSuffix(content, "\n")
+	if content != "" {
+		e.InsertString(rowId, content)
+	}
+	return rowId, nil
+}
+
+// SearchLines finds the first row (start..end) containing find, and its rune column
+func SearchLines(e LineEditor, start, end int, find string) (rowId, col int) {
+	for rowId = start; rowId <= end; rowId++ {
+		str := e.Line(rowId)
+		if ix := strings.Index(str, find); ix >= 0 {
+			return rowId, utf8.RuneCountInString(str[:ix])
+		}
+	}
+	return -1, -1
+}
+
+// ReplaceLines replaces every find with repl in the rows start..end, left to right as EDLIN does.
+// A replacement is not searched again. It returns the number replaced and the last row changed.
+func ReplaceLines(e LineEditor, start, end int, find, repl string) (n, last int) {
+	last = -1
+	if find == "" {
+		return
+	}
+	for rowId := start; rowId <= end; rowId++ {
+		for col := 0; ; {
+			runes := []rune(e.Line(rowId))
+			ix := strings.Index(string(runes[col:]), find)
+			if ix < 0 {
+				break
+			}
+			col1 := col + utf8.RuneCountInString(string(runes[col:])[:ix])
+			e.Splice(rowId, col1, col1+utf8.RuneCountInString(find)-1, repl)
+			col = col1 + utf8.RuneCountInString(repl)
+			n++
+			last = rowId
+		}
+	}
+	return
+}
+
+func lineStrings(e LineEditor, start, end int) (rows []string) {
+	for rowId := start; rowId <= end; rowId++ {
+		rows = append(rows, e.Line(rowId))
+	}
+	return
+}
@@ -0,0 +1,223 @@
+package buffer
+
+import (
+	"slices"
+	"testing"
+)
+
+/*
+
+  File:    command_test.go
+  Author:  Bob Shofner
+
+  MIT License - https://opensource.org/license/mit/
+
+  This permission notice shall be included in all copies
+    or substantial portions of the Software.
+
+*/
+/*
+  Description: tests of the EDLIN command parsing and line commands.
+	The commands edit a Buffer, the headless LineEditor.
+*/
+
+func TestParseCommand(t *testing.T) {
+	tests := []struct {
+		str  string
+		want Command
+		err  bool
+	}{
+		{"", Command{}, false},
+		{"5", Command{Params: []int{5}}, false},
+		{" 1,3l", Command{Params: []int{1, 3}, Op: 'L'}, false},
+		{".,.+2D", Command{Params: []int{4, 6}, Op: 'D'}, false},
+		{"-1,$P", Command{Params: []int{3, 10}, Op: 'P'}, false},
+		{"#I", Command{Params: []int{11}, Op: 'I'}, false},
+		{"99", Command{Params: []int{11}}, false},
+		{",5L", Command{Params: []int{0, 5}, Op: 'L'}, false},
+		{"1,2,3,4C", Command{Params: []int{1, 2, 3, 4}, Op: 'C'}, false},
+		{"?Sfind me", Command{Query: true, Op: 'S', Text: "find me"}, false},
+		{"1,$?Rold\x1anew", Command{Params: []int{1, 10}, Query: true, Op: 'R', Text: "old\x1anew"}, false},
+		{"-4L", Command{}, true},
+		{"1;2L", Command{}, true},
+		{"5?", Command{}, true},
+		{"1,$+", Command{}, true},
+	}
+	for _, tt := range tests {
+		cmd, err := ParseCommand(tt.str, 4, 10)
+		if tt.err {
+			if err == nil {
+				t.Errorf("%q: no error", tt.str)
+			}
+			continue
+		}
+		if err != nil || !slices.Equal(cmd.Params, tt.want.Params) || cmd.Query != tt.want.Query ||
+			cmd.Op != tt.want.Op || cmd.Text != tt.want.Text {
+			t.Errorf("%q = %+v, %v; want %+v", tt.str, cmd, err, tt.want)
+		}
+	}
+}
+
+func TestSplitReplace(t *testing.T) {
+	tests := []struct{ text, find, repl string }{
+		{"a\x1ab", "a", "b"},
+		{"a^Zb", "a", "b"},
+		{"a^zb", "a", "b"},
+		{"a^Z", "a", ""},
+		{"a", "a", ""},
+	}
+	for _, tt := range tests {
+		if find, repl := SplitReplace(tt.text); find != tt.find || repl != tt.repl {
+			t.Errorf("%q = %q, %q", tt.text, find, repl)
+		}
+	}
+}
+
+func TestEditLine(t *testing.T) {
+	tests := []struct {
+		str            string
+		current, count int
+		rowId          int
+		err            bool
+	}{
+		{"", 1, 3, 1, false},
+		{"", 3, 3, 2, false},
+		{"2", 1, 3, 1, false},
+		{"#", 1, 3, 0, true},
+		{"1,2", 1, 3, 0, true},
+		{"", 1, 0, 0, true},
+		{"1", 1, 0, 0, true},
+	}
+	for _, tt := range tests {
+		cmd, err := ParseCommand(tt.str, tt.current, tt.count)
+		if err != nil {
+			t.Fatalf("%q: %v", tt.str, err)
+		}
+		rowId, err := EditLine(cmd, tt.current, tt.count)
+		if (err != nil) != tt.err || (!tt.err && rowId != tt.rowId) {
+			t.Errorf("%q at %d of %d = %d, %v; want %d", tt.str, tt.current, tt.count, rowId, err, tt.rowId)
+		}
+	}
+}
+
+func TestLineRanges(t *testing.T) {
+	tests := []struct {
+		str            string
+		current, count int
+		list, page     [2]int // 0 based, end < start is none
+		err            bool
+	}{
+		{"L", 1, 0, [2]int{0, -1}, [2]int{0, -1}, false},
+		{"1,5L", 1, 0, [2]int{0, -1}, [2]int{0, -1}, false},
+		{"L", 1, 3, [2]int{0, 2}, [2]int{0, 2}, false},
+		{"L", 20, 50, [2]int{8, 30}, [2]int{20, 42}, false},
+		{"5,7L", 1, 50, [2]int{4, 6}, [2]int{4, 6}, false},
+		{",30L", 1, 50, [2]int{7, 29}, [2]int{1, 29}, false},
+		{"9,5L", 1, 50, [2]int{}, [2]int{}, true},
+	}
+	for _, tt := range tests {
+		cmd, err := ParseCommand(tt.str, tt.current, tt.count)
+		if err != nil {
+			t.Fatalf("%q: %v", tt.str, err)
+		}
+		for i, lines := range []func(Command, int, int) (int, int, error){ListLines, PageLines} {
+			want := [2]int{tt.list[0], tt.list[1]}
+			if i == 1 {
+				want = tt.page
+			}
+			start, end, err := lines(cmd, tt.current, tt.count)
+			if (err != nil) != tt.err || (!tt.err && [2]int{start, end} != want) {
+				t.Errorf("%q (%c) at %d of %d = %d..%d, %v; want %v", tt.str, "LP"[i],
+					tt.current, tt.count, start, end, err, want)
+			}
+		}
+	}
+}
+
+func TestSearchLines(t *testing.T) {
+	tests := []struct {
+		lines      []string
+		start, end int
+		find       string
+		row, col   int
+	}{
+		{[]string{"aaab"}, 0, 0, "aab", 0, 1},
+		{[]string{"x", "éaé"}, 0, 1, "aé", 1, 1},
+		{[]string{"a", "b", "a"}, 1, 2, "a", 2, 0},
+		{[]string{"a", "b"}, 0, 1, "c", -1, -1},
+	}
+	for _, tt := range tests {
+		row, col := SearchLines(NewBuffer(tt.lines...), tt.start, tt.end, tt.find)
+		if row != tt.row || col != tt.col {
+			t.Errorf("%q in %q = %d, %d; want %d, %d", tt.find, tt.lines, row, col, tt.row, tt.col)
+		}
+	}
+}
+
+func TestReplaceLines(t *testing.T) {
+	tests := []struct {
+		lines      []string
+		find, repl string
+		want       []string
+		n, last    int
+	}{
+		{[]string{"aaa"}, "aa", "b", []string{"ba"}, 1, 0},
+		{[]string{"aaab"}, "aab", "x", []string{"ax"}, 1, 0},
+		{[]string{"a-a"}, "a", "aa", []string{"aa-aa"}, 2, 0},
+		{[]string{"éa", "b", "aé"}, "a", "", []string{"é", "b", "é"}, 2, 2},
+		{[]string{"abc"}, "x", "y", []string{"abc"}, 0, -1},
+		{[]string{"abc"}, "", "y", []string{"abc"}, 0, -1},
+	}
+	for _, tt := range tests {
+		e := NewBuffer(tt.lines...)
+		n, last := ReplaceLines(e, 0, e.Len()-1, tt.find, tt.repl)
+		got := e.Lines(0, e.Len()-1)
+		if n != tt.n || last != tt.last || !slices.Equal(got, tt.want) {
+			t.Errorf("%q -> %q in %q = %q, %d, %d; want %q, %d, %d",
+				tt.find, tt.repl, tt.lines, got, n, last, tt.want, tt.n, tt.last)
+		}
+	}
+}
+
+func TestLineCommands(t *testing.T) {
+	run := map[rune]func(LineEditor, Command, int) (int, error){
+		'D': DeleteCommand,
+		'C': CopyMoveCommand,
+		'M': CopyMoveCommand,
+	}
+	tests := []struct {
+		str     string
+		current int
+		want    []string
+		rowId   int
+		err     bool
+	}{
+		{"D", 2, []string{"1", "3", "4", "5"}, 1, false},
+		{"2,3D", 1, []string{"1", "4", "5"}, 1, false},
+		{"4,6D", 1, nil, 0, true},
+		{"1,2,4C", 1, []string{"1", "2", "3", "1", "2", "4", "5"}, 3, false},
+		{"1,1,6,2C", 1, []string{"1", "2", "3", "4", "5", "1", "1"}, 5, false},
+		{"1,2,2C", 1, nil, 0, true},
+		{"1,2,5M", 1, []string{"3", "4", "1", "2", "5"}, 2, false},
+		{"4,5,1M", 1, []string{"4", "5", "1", "2", "3"}, 0, false},
+		{"1,2M", 1, nil, 0, true},
+		{"1,2,5,2M", 1, nil, 0, true},
+	}
+	for _, tt := range tests {
+		e := NewBuffer("1", "2", "3", "4", "5")
+		cmd, err := ParseCommand(tt.str, tt.current, e.Len())
+		if err != nil {
+			t.Fatalf("%q: %v", tt.str, err)
+		}
+		rowId, err := run[cmd.Op](e, cmd, tt.current)
+		if tt.err {
+			if err == nil {
+				t.Errorf("%q: no error", tt.str)
+			}
+			continue
+		}
+		if got := e.Lines(0, e.Len()-1); err != nil || rowId != tt.rowId || !slices.Equal(got, tt.want) {
+			t.Errorf("%q = %q, %d, %v; want %q, %d", tt.str, got, rowId, err, tt.want, tt.rowId)
+		}
+	}
+}
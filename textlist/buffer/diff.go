@@ -0,0 +1,149 @@
+package buffer
+
+import (
+	"fmt"
+	"slices"
+)
+
+/*
+
+  File:    diff.go
+  Author:  Bob Shofner
+
+  MIT License - https://opensource.org/license/mit/
+
+  This permission notice shall be included in all copies
+    or substantial portions of the Software.
+
+*/
+/*
+  Description: Diff compares two versions of the lines of a file.
+	Myers' O(ND) algorithm finds the fewest lines deleted and inserted.
+	Very different files (more than maxDiff changes) are shown as the
+	changed middle deleted and inserted, to bound the time and memory.
+*/
+
+const maxDiff = 2000
+
+// DiffOp is one line of a Diff. Kind is ' ' (same), '-' (deleted) or '+' (inserted).
+type DiffOp struct {
+	Kind byte
+	Line string
+}
+
+// Diff returns the edit from the lines a to the lines b
+func Diff(a, b []string) []DiffOp {
+	var ops []DiffOp
+	// the common start and end are not searched
+	start := 0
+	for start < len(a) && start < len(b) && a[start] == b[start] {
+		ops = append(ops, DiffOp{' ', a[start]})
+		start++
+	}
+	end := 0
+	for end < len(a)-start && end < len(b)-start && a[len(a)-1-end] == b[len(b)-1-end] {
+		end++
+	}
+	ops = append(ops, myers(a[start:len(a)-end], b[start:len(b)-end])...)
+	for _, line := range a[len(a)-end:] {
+		ops = append(ops, DiffOp{' ', line})
+	}
+	return ops
+}
+
+// myers finds the shortest edit script, keeping the furthest x of each diagonal k for each d
+func myers(a, b []string) []DiffOp {
+	n, m := len(a), len(b)
+	most := min(n+m, maxDiff)
+	off := most + 1
+	v := make([]int, 2*most+3)
+	var trace [][]int // v[-d-1 .. d+1] before step d
+	for d := 0; d <= most; d++ {
+		trace = append(trace, slices.Clone(v[off-d-1:off+d+2]))
+		for k := -d; k <= d; k += 2 {
+			x := v[off+k-1] + 1
+			if k == -d || (k != d && v[off+k-1] < v[off+k+1]) {
+				x = v[off+k+1]
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[off+k] = x
+			if x >= n && y >= m {
+				return backtrack(trace, a, b)
+			}
+		}
+	}
+	// too different
+	ops := make([]DiffOp, 0, n+m)
+	for _, line := range a {
+		ops = append(ops, DiffOp{'-', line})
+	}
+	for _, line := range b {
+		ops = append(ops, DiffOp{'+', line})
+	}
+	return ops
+}
+
+func backtrack(trace [][]int, a, b []string) []DiffOp {
+	var ops []DiffOp
+	x, y := len(a), len(b)
+	for d := len(trace) - 1; d >= 0; d-- {
+		v := func(k int) int { return trace[d][k+d+1] }
+		k := x - y
+		prevK := k - 1
+		if k == -d || (k != d && v(k-1) < v(k+1)) {
+			prevK = k + 1
+		}
+		prevX := v(prevK)
+		prevY := prevX - prevK
+		for x > prevX && y > prevY {
+			ops = append(ops, DiffOp{' ', a[x-1]})
+			x--
+			y--
+		}
+		if d > 0 {
+			if x == prevX {
+				ops = append(ops, DiffOp{'+', b[y-1]})
+				y--
+			} else {
+				ops = append(ops, DiffOp{'-', a[x-1]})
+				x--
+			}
+		}
+	}
+	slices.Reverse(ops)
+	return ops
+}
+
+// Unified shows the changes of a Diff, with context lines around each.
+// Each group of changes starts with the line numbers (1 based) of a and b.
+func Unified(ops []DiffOp, context int) []string {
+	show := make([]bool, len(ops))
+	for i, op := range ops {
+		if op.Kind != ' ' {
+			for j := max(i-context, 0); j <= min(i+context, len(ops)-1); j++ {
+				show[j] = true
+			}
+		}
+	}
+	var lines []string
+	la, lb := 1, 1
+	for i, op := range ops {
+		if show[i] {
+			if i == 0 || !show[i-1] {
+				lines = append(lines, fmt.Sprintf("@@ -%d +%d @@", la, lb))
+			}
+			lines = append(lines, string(op.Kind)+" "+op.Line)
+		}
+		if op.Kind != '+' {
+			la++
+		}
+		if op.Kind != '-' {
+			lb++
+		}
+	}
+	return lines
+}
@@ -0,0 +1,104 @@
+package buffer
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+/*
+
+  File:    diff_test.go
+  Author:  Bob Shofner
+
+  MIT License - https://opensource.org/license/mit/
+
+  This permission notice shall be included in all copies
+    or substantial portions of the Software.
+
+*/
+/*
+  Description: tests of Diff and Unified.
+*/
+
+// ops shows a Diff compactly, as "-a +b  c"
+func ops(diff []DiffOp) string {
+	var s []string
+	for _, op := range diff {
+		s = append(s, string(op.Kind)+op.Line)
+	}
+	return strings.Join(s, " ")
+}
+
+// apply makes the lines a and b from a Diff
+func apply(diff []DiffOp) (a, b []string) {
+	for _, op := range diff {
+		if op.Kind != '+' {
+			a = append(a, op.Line)
+		}
+		if op.Kind != '-' {
+			b = append(b, op.Line)
+		}
+	}
+	return
+}
+
+func TestDiff(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want string
+	}{
+		{"", "", ""},
+		{"a b", "a b", " a  b"},
+		{"", "a", "+a"},
+		{"a", "", "-a"},
+		{"a b c", "a c", " a -b  c"},
+		{"a c", "a b c", " a +b  c"},
+		{"a b", "a x", " a -b +x"},
+		{"x a b", "a b y", "-x  a  b +y"},
+		{"a b c a b b a", "c b a b a c", "-a -b  c +b  a  b -b  a +c"},
+	}
+	for _, tt := range tests {
+		a, b := strings.Fields(tt.a), strings.Fields(tt.b)
+		diff := Diff(a, b)
+		if got := ops(diff); got != tt.want {
+			t.Errorf("%q to %q = %q, want %q", tt.a, tt.b, got, tt.want)
+		}
+		if da, db := apply(diff); !slices.Equal(da, a) || !slices.Equal(db, b) {
+			t.Errorf("%q to %q makes %q and %q", tt.a, tt.b, da, db)
+		}
+	}
+}
+
+func TestDiffLarge(t *testing.T) {
+	var a, b []string
+	for i := range 3000 {
+		a = append(a, strings.Repeat("a", i%7))
+		b = append(b, strings.Repeat("b", i%5))
+	}
+	if da, db := apply(Diff(a, b)); !slices.Equal(da, a) || !slices.Equal(db, b) {
+		t.Error("past maxDiff the lines are not kept")
+	}
+}
+
+func TestUnified(t *testing.T) {
+	a := strings.Fields("1 2 3 4 5 6 7 8 9")
+	b := strings.Fields("1 x 3 4 5 6 7 8 y 9")
+	want := []string{
+		"@@ -1 +1 @@",
+		"  1",
+		"- 2",
+		"+ x",
+		"  3",
+		"@@ -8 +8 @@",
+		"  8",
+		"+ y",
+		"  9",
+	}
+	if got := Unified(Diff(a, b), 1); !slices.Equal(got, want) {
+		t.Errorf("Unified =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+	if got := Unified(Diff(a, a), 2); len(got) != 0 {
+		t.Errorf("no changes shows %q", got)
+	}
+}
@@ -0,0 +1,145 @@
+package buffer
+
+import (
+	"bytes"
+	"fmt"
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/unicode"
+	"golang.org/x/text/encoding/unicode/utf32"
+	"golang.org/x/text/transform"
+	"io"
+	"unicode/utf8"
+)
+
+/*
+
+  File:    encoding.go
+  Author:  Bob Shofner
+
+  MIT License - https://opensource.org/license/mit/
+
+  This permission notice shall be included in all copies
+    or substantial portions of the Software.
+
+*/
+/*
+  Description: character encodings of a file.
+	A Buffer is always UTF-8. A file is decoded when read and encoded
+	when written. UTF-16 and UTF-32 are found by their BOM; other files
+	are UTF-8 when valid, else the chosen legacy (8 bit) encoding.
+*/
+
+const (
+	UTF8    = "UTF-8"
+	UTF16LE = "UTF-16LE"
+	UTF16BE = "UTF-16BE"
+	UTF32LE = "UTF-32LE"
+	UTF32BE = "UTF-32BE"
+)
+
+// DefaultLegacyEncoding decodes a file that is not UTF-8 and has no BOM
+const DefaultLegacyEncoding = "Windows-1252"
+
+var encodings = map[string]encoding.Encoding{
+	UTF8:           nil,
+	UTF16LE:        unicode.UTF16(unicode.LittleEndian, unicode.IgnoreBOM),
+	UTF16BE:        unicode.UTF16(unicode.BigEndian, unicode.IgnoreBOM),
+	UTF32LE:        utf32.UTF32(utf32.LittleEndian, utf32.IgnoreBOM),
+	UTF32BE:        utf32.UTF32(utf32.BigEndian, utf32.IgnoreBOM),
+	"Windows-1252": charmap.Windows1252,
+	"Windows-1250": charmap.Windows1250,
+	"Windows-1251": charmap.Windows1251,
+	"ISO-8859-1":   charmap.ISO8859_1,
+	"ISO-8859-2":   charmap.ISO8859_2,
+	"ISO-8859-15":  charmap.ISO8859_15,
+	"CP437 (DOS)":  charmap.CodePage437,
+	"CP850 (DOS)":  charmap.CodePage850,
+	"Macintosh":    charmap.Macintosh,
+	"KOI8-R":       charmap.KOI8R,
+}
+
+// byte order marks, longest first (the UTF-32LE BOM starts with the UTF-16LE BOM)
+var boms = []struct {
+	name string
+	bom  []byte
+}{
+	{UTF32LE, []byte{0xff, 0xfe, 0, 0}},
+	{UTF32BE, []byte{0, 0, 0xfe, 0xff}},
+	{UTF8, utf8BOM},
+	{UTF16LE, []byte{0xff, 0xfe}},
+	{UTF16BE, []byte{0xfe, 0xff}},
+}
+
+// UnicodeEncodings are the encodings that may have a BOM
+var UnicodeEncodings = []string{UTF8, UTF16LE, UTF16BE, UTF32LE, UTF32BE}
+
+// LegacyEncodings are the 8 bit encodings
+var LegacyEncodings = []string{"Windows-1252", "Windows-1250", "Windows-1251",
+	"ISO-8859-1", "ISO-8859-2", "ISO-8859-15", "CP437 (DOS)", "CP850 (DOS)", "Macintosh", "KOI8-R"}
+
+// IsUnicode reports whether name is a Unicode (not legacy) encoding
+func IsUnicode(name string) bool {
+	for _, u := range UnicodeEncodings {
+		if u == name {
+			return true
+		}
+	}
+	return false
+}
+
+// decode converts file content to UTF-8, returning the encoding found and
+// whether it had a BOM. legacy is used for content that is not UTF-8.
+func decode(data []byte, legacy string) (text []byte, name string, bom bool, err error) {
+	for _, b := range boms {
+		if bytes.HasPrefix(data, b.bom) {
+			name, bom, data = b.name, true, data[len(b.bom):]
+			break
+		}
+	}
+	if name == "" {
+		name = UTF8
+		if !utf8.Valid(data) {
+			name = legacy
+		}
+	}
+	enc, ok := encodings[name]
+	if !ok {
+		return nil, "", false, fmt.Errorf("unknown encoding %s", name)
+	}
+	if enc == nil {
+		return data, name, bom, nil
+	}
+	text, err = enc.NewDecoder().Bytes(data)
+	return text, name, bom, err
+}
+
+// bomFor returns the byte order mark of an encoding (nil for legacy)
+func bomFor(name string) []byte {
+	for _, b := range boms {
+		if b.name == name {
+			return b.bom
+		}
+	}
+	return nil
+}
+
+// encoder wraps w to encode UTF-8 in the named encoding. Close flushes, but does not close w.
+func encoder(w io.Writer, name string) (io.WriteCloser, error) {
+	enc, ok := encodings[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown encoding %s", name)
+	}
+	if enc == nil {
+		return nopCloser{w}, nil
+	}
+	return transform.NewWriter(w, enc.NewEncoder()), nil
+}
+
+type nopCloser struct {
+	io.Writer
+}
+
+func (nopCloser) Close() error {
+	return nil
+}
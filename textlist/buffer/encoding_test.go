@@ -0,0 +1,86 @@
+package buffer
+
+import (
+	"bytes"
+	"slices"
+	"testing"
+)
+
+/*
+
+  File:    encoding_test.go
+  Author:  Bob Shofner
+
+  MIT License - https://opensource.org/license/mit/
+
+  This permission notice shall be included in all copies
+    or substantial portions of the Software.
+
+*/
+/*
+  Description: tests of reading and writing the Unicode and legacy encodings.
+*/
+
+func TestEncodingRoundTrip(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     []byte
+		legacy   string
+		encoding string
+		lines    []string
+	}{
+		{"UTF-16LE BOM", []byte("\xff\xfea\x00\n\x00\xe9\x00\n\x00"), DefaultLegacyEncoding, UTF16LE, []string{"a", "é"}},
+		{"UTF-16BE BOM", []byte("\xfe\xff\x00a\x00\r\x00\n"), DefaultLegacyEncoding, UTF16BE, []string{"a"}},
+		{"UTF-32LE BOM", []byte("\xff\xfe\x00\x00a\x00\x00\x00"), DefaultLegacyEncoding, UTF32LE, []string{"a"}},
+		{"Windows-1252", []byte("caf\xe9 \x80\n"), DefaultLegacyEncoding, "Windows-1252", []string{"café €"}},
+		{"Windows-1251", []byte("\xcc\xe8\xf0\n"), "Windows-1251", "Windows-1251", []string{"Мир"}},
+		{"UTF-8 is not legacy", []byte("café\n"), "Windows-1251", UTF8, []string{"café"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, err := NewBufferEncoding(tt.data, tt.legacy)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := b.Lines(0, b.Len()-1); !slices.Equal(got, tt.lines) || b.Format().Encoding != tt.encoding {
+				t.Errorf("lines %q (%s), want %q (%s)", got, b.Format().Encoding, tt.lines, tt.encoding)
+			}
+			if got := write(t, b); !bytes.Equal(got, tt.data) {
+				t.Errorf("wrote %q, want %q", got, tt.data)
+			}
+		})
+	}
+	if _, err := NewBufferEncoding([]byte("\xe9"), "EBCDIC"); err == nil {
+		t.Error("an unknown encoding was read")
+	}
+}
+
+func TestSetEncoding(t *testing.T) {
+	tests := []struct {
+		name   string
+		format Format
+		want   string
+	}{
+		{"UTF-16BE", Format{Encoding: UTF16BE, BOM: true, FinalNewline: true}, "\xfe\xff\x00a\x00\n\x00\xe9\x00\n"},
+		{"UTF-16LE no BOM", Format{Encoding: UTF16LE}, "a\x00\n\x00\xe9\x00"},
+		{"no legacy BOM", Format{Encoding: DefaultLegacyEncoding, BOM: true, FinalNewline: true}, "a\n\xe9\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := NewBuffer("a", "é")
+			b.SetFormat(tt.format)
+			if got := write(t, b); !bytes.Equal(got, []byte(tt.want)) {
+				t.Errorf("wrote %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWriteUnencodable(t *testing.T) {
+	b := NewBuffer("a €", "日本")
+	b.SetFormat(Format{Encoding: "Windows-1252"})
+	var out bytes.Buffer
+	if _, err := b.WriteTo(&out); err == nil {
+		t.Errorf("wrote %q, want an error for 日本", out.Bytes())
+	}
+}
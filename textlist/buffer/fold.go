@@ -0,0 +1,110 @@
+package buffer
+
+import (
+	"golang.org/x/text/cases"
+	"golang.org/x/text/language"
+	"golang.org/x/text/unicode/norm"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+/*
+
+  File:    fold.go
+  Author:  Bob Shofner
+
+  MIT License - https://opensource.org/license/mit/
+
+  This permission notice shall be included in all copies
+    or substantial portions of the Software.
+
+*/
+/*
+  Description: the Unicode rules of search and replace.
+	Ignoring case uses full Unicode case folding (ß matches ss), not
+	rune by rune lower case. Normalizing matches canonically equivalent
+	text (é matches e followed by a combining acute accent). A match
+	must begin and end on whole runes of the line, so its cells can be
+	marked.
+*/
+
+// SearchOptions choose how text is matched
+type SearchOptions struct {
+	IgnoreCase bool // Unicode case folding
+	WholeWord  bool // the match is not part of a longer word
+	Normalize  bool // NFC and NFD forms of the same text match
+}
+
+// foldRunes returns runes as they are compared, and the column (of runes) each came from.
+// folder (cases.Fold) is made once for a search: it is not safe for concurrent use.
+func foldRunes(runes []rune, opt SearchOptions, folder cases.Caser) (folded []rune, cols []int) {
+	folded = make([]rune, 0, len(runes))
+	cols = make([]int, 0, len(runes))
+	for col, r := range runes {
+		if r < utf8.RuneSelf {
+			if opt.IgnoreCase && 'A' <= r && r <= 'Z' {
+				r += 'a' - 'A'
+			}
+			folded = append(folded, r)
+			cols = append(cols, col)
+			continue
+		}
+		s := string(r)
+		if opt.Normalize {
+			s = norm.NFD.String(s)
+		}
+		if opt.IgnoreCase {
+			s = folder.String(s)
+		}
+		if opt.Normalize {
+			s = norm.NFD.String(s)
+		}
+		for _, f := range s {
+			folded = append(folded, f)
+			cols = append(cols, col)
+		}
+	}
+	return folded, cols
+}
+
+// isWholeWord reports whether the cells of m are not part of a longer word of runes
+func isWholeWord(runes []rune, m Match) bool {
+	return (m.Col1 == 0 || !isWordRune(runes[m.Col1-1])) &&
+		(m.Col2+1 >= len(runes) || !isWordRune(runes[m.Col2+1]))
+}
+
+func isWordRune(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.Is(unicode.Mn, r)
+}
+
+// PreserveCase gives replacement the case of matched: foo→bar, Foo→Bar and FOO→BAR.
+// Mixed case (fOO) leaves replacement as it is.
+func PreserveCase(matched, replacement string) string {
+	upper, lower := 0, 0
+	first := rune(0) // the first letter
+	for _, r := range matched {
+		switch {
+		case unicode.IsUpper(r):
+			upper++
+		case unicode.IsLower(r):
+			lower++
+		default:
+			continue
+		}
+		if first == 0 {
+			first = r
+		}
+	}
+	switch {
+	case replacement == "":
+	case upper > 1 && lower == 0:
+		return cases.Upper(language.Und).String(replacement) // ß is SS
+	case upper == 0 && lower > 0:
+		return strings.ToLower(replacement)
+	case upper == 1 && unicode.IsUpper(first):
+		r, size := utf8.DecodeRuneInString(replacement)
+		return string(unicode.ToTitle(r)) + strings.ToLower(replacement[size:])
+	}
+	return replacement
+}
@@ -0,0 +1,74 @@
+package buffer
+
+import (
+	"golang.org/x/text/cases"
+	"slices"
+	"testing"
+)
+
+/*
+
+  File:    fold_test.go
+  Author:  Bob Shofner
+
+  MIT License - https://opensource.org/license/mit/
+
+  This permission notice shall be included in all copies
+    or substantial portions of the Software.
+
+*/
+/*
+  Description: tests of case folding, whole words and preserving case.
+*/
+
+func TestFoldRunes(t *testing.T) {
+	folded, cols := foldRunes([]rune("Aß"), SearchOptions{IgnoreCase: true}, cases.Fold())
+	if string(folded) != "ass" || !slices.Equal(cols, []int{0, 1, 1}) {
+		t.Errorf("folded %q, cols %v", string(folded), cols)
+	}
+	folded, cols = foldRunes([]rune("é!"), SearchOptions{Normalize: true}, cases.Fold())
+	if string(folded) != "é!" || !slices.Equal(cols, []int{0, 0, 1}) {
+		t.Errorf("normalized %q, cols %v", string(folded), cols)
+	}
+}
+
+func TestIsWholeWord(t *testing.T) {
+	runes := []rune("a_go gö go")
+	tests := []struct {
+		m    Match
+		want bool
+	}{
+		{Match{0, 2, 3}, false}, // after _
+		{Match{0, 5, 5}, false}, // before ö
+		{Match{0, 8, 9}, true},  // the end of the line
+		{Match{0, 0, 3}, true},  // the start of the line
+	}
+	for _, tt := range tests {
+		if got := isWholeWord(runes, tt.m); got != tt.want {
+			t.Errorf("isWholeWord %q = %v, want %v", string(runes[tt.m.Col1:tt.m.Col2+1]), got, tt.want)
+		}
+	}
+}
+
+func TestPreserveCase(t *testing.T) {
+	tests := []struct {
+		matched, replacement, want string
+	}{
+		{"foo", "bar", "bar"},
+		{"Foo", "bar", "Bar"},
+		{"FOO", "bar", "BAR"},
+		{"foo", "BAR", "bar"},
+		{"Foo", "bAR", "Bar"},
+		{"fOO", "bar", "bar"}, // mixed: as it is
+		{"F", "bar", "Bar"},   // one capital is title case
+		{"123", "bar", "bar"}, // no letters
+		{"Foo", "", ""},
+		{"Öl", "über", "Über"},
+		{"STRASSE", "straße", "STRASSE"},
+	}
+	for _, tt := range tests {
+		if got := PreserveCase(tt.matched, tt.replacement); got != tt.want {
+			t.Errorf("PreserveCase(%q, %q) = %q, want %q", tt.matched, tt.replacement, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,156 @@
+package buffer
+
+import (
+	"bufio"
+	"io"
+	"strings"
+)
+
+/*
+
+  File:    format.go
+  Author:  Bob Shofner
+
+  MIT License - https://opensource.org/license/mit/
+
+  This permission notice shall be included in all copies
+    or substantial portions of the Software.
+
+*/
+/*
+  Description: Format is how a Buffer is stored in a file.
+	The encoding, BOM, line ending and final newline are found when a file
+	is read, and used again when it is written, so a file round-trips.
+*/
+
+// LineEnding is the line separator of a file
+type LineEnding int
+
+const (
+	LF   LineEnding = iota // Unix
+	CRLF                   // Windows / DOS
+	CR                     // classic Mac
+)
+
+var lineEndings = []string{"\n", "\r\n", "\r"}
+var lineEndingNames = []string{"LF", "CRLF", "CR"}
+
+func (e LineEnding) String() string {
+	return lineEndingNames[e]
+}
+
+// Bytes returns the line separator
+func (e LineEnding) Bytes() []byte {
+	return []byte(lineEndings[e])
+}
+
+// Format describes the file layout of a Buffer
+type Format struct {
+	Encoding     string
+	Ending       LineEnding
+	BOM          bool // byte order mark (of a Unicode Encoding)
+	FinalNewline bool // the last line ends with Ending
+	Mixed        bool // the file had more than one line ending
+}
+
+var utf8BOM = []byte{0xef, 0xbb, 0xbf}
+
+// DefaultFormat is the Format of a new Buffer
+var DefaultFormat = Format{Encoding: UTF8, Ending: LF, FinalNewline: true}
+
+// DetectFormat finds the line endings of (UTF-8) file content. The most used line ending wins.
+func DetectFormat(data []byte) Format {
+	f := DefaultFormat
+	var counts [3]int
+	for i := 0; i < len(data); i++ {
+		switch data[i] {
+		case '\n':
+			counts[LF]++
+		case '\r':
+			if i+1 < len(data) && data[i+1] == '\n' {
+				counts[CRLF]++
+				i++
+			} else {
+				counts[CR]++
+			}
+		}
+	}
+	used := 0
+	for e, n := range counts {
+		if n > 0 {
+			used++
+		}
+		if n > counts[f.Ending] {
+			f.Ending = LineEnding(e)
+		}
+	}
+	f.Mixed = used > 1
+	if len(data) > 0 {
+		last := data[len(data)-1]
+		f.FinalNewline = last == '\n' || last == '\r'
+	}
+	return f
+}
+
+// String describes the Format for a status display
+func (f Format) String() string {
+	s := []string{f.Encoding}
+	if f.BOM {
+		s = append(s, "BOM")
+	}
+	s = append(s, f.Ending.String())
+	if f.Mixed {
+		s[len(s)-1] += "(mixed)"
+	}
+	if !f.FinalNewline {
+		s = append(s, "no final newline")
+	}
+	return strings.Join(s, " ")
+}
+
+// Format returns how the Buffer is written
+func (b *Buffer) Format() Format {
+	return b.format
+}
+
+// SetFormat changes how the Buffer is written. Only Unicode encodings have a BOM.
+func (b *Buffer) SetFormat(f Format) {
+	f.Mixed = false
+	f.BOM = f.BOM && IsUnicode(f.Encoding)
+	if f != b.format {
+		b.format = f
+		b.changed()
+	}
+}
+
+// WriteTo writes the lines to w using the Buffer Format.
+// It fails if a character can not be encoded.
+func (b *Buffer) WriteTo(w io.Writer) (int64, error) {
+	if b.format.BOM {
+		if _, err := w.Write(bomFor(b.format.Encoding)); err != nil {
+			return 0, err
+		}
+	}
+	ew, err := encoder(w, b.format.Encoding)
+	if err != nil {
+		return 0, err
+	}
+	bw := bufio.NewWriter(ew)
+	var n int64
+	write := func(p []byte) {
+		m, _ := bw.Write(p)
+		n += int64(m)
+	}
+	ending := b.format.Ending.Bytes()
+	last := b.Len() - 1
+	for ix, line := range b.All() {
+		write([]byte(line))
+		if ix < last || b.format.FinalNewline {
+			write(ending)
+		}
+	}
+	if err := bw.Flush(); err != nil {
+		return n, err
+	}
+	return n, ew.Close()
+}
@@ -0,0 +1,122 @@
+package buffer
+
+import (
+	"bytes"
+	"slices"
+	"testing"
+)
+
+/*
+
+  File:    format_test.go
+  Author:  Bob Shofner
+
+  MIT License - https://opensource.org/license/mit/
+
+  This permission notice shall be included in all copies
+    or substantial portions of the Software.
+
+*/
+/*
+  Description: tests of the Format of a file: line endings, BOM and final newline.
+*/
+
+// write returns the file content of b
+func write(t *testing.T, b *Buffer) []byte {
+	t.Helper()
+	var out bytes.Buffer
+	if _, err := b.WriteTo(&out); err != nil {
+		t.Fatal(err)
+	}
+	return out.Bytes()
+}
+
+func TestBufferRoundTrip(t *testing.T) {
+	tests := []struct {
+		name  string
+		data  []byte
+		lines []string
+	}{
+		{"empty", []byte(""), nil},
+		{"no newline", []byte("a"), []string{"a"}},
+		{"LF", []byte("a\n"), []string{"a"}},
+		{"empty line", []byte("\n"), []string{""}},
+		{"blank lines", []byte("a\n\nb\n"), []string{"a", "", "b"}},
+		{"CRLF", []byte("a\r\nb\r\n"), []string{"a", "b"}},
+		{"CR", []byte("a\rb"), []string{"a", "b"}},
+		{"UTF-8 BOM", []byte("\xef\xbb\xbfa\n"), []string{"a"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := NewBufferBytes(tt.data)
+			if lines := b.Lines(0, b.Len()-1); !slices.Equal(lines, tt.lines) {
+				t.Errorf("lines %q, want %q", lines, tt.lines)
+			}
+			if got := write(t, b); !bytes.Equal(got, tt.data) {
+				t.Errorf("wrote %q, want %q (%s)", got, tt.data, b.Format())
+			}
+		})
+	}
+}
+
+func TestDetectFormat(t *testing.T) {
+	tests := []struct {
+		data  string
+		want  Format
+		shown string
+	}{
+		{"", DefaultFormat, "UTF-8 LF"},
+		{"a", Format{Encoding: UTF8, Ending: LF}, "UTF-8 LF no final newline"},
+		{"a\nb\n", DefaultFormat, "UTF-8 LF"},
+		{"a\r\nb\r\n", Format{Encoding: UTF8, Ending: CRLF, FinalNewline: true}, "UTF-8 CRLF"},
+		{"a\rb", Format{Encoding: UTF8, Ending: CR}, "UTF-8 CR no final newline"},
+		{"a\r\nb\nc\r\n", Format{Encoding: UTF8, Ending: CRLF, FinalNewline: true, Mixed: true}, "UTF-8 CRLF(mixed)"},
+		{"a\nb\r", Format{Encoding: UTF8, Ending: LF, FinalNewline: true, Mixed: true}, "UTF-8 LF(mixed)"},
+	}
+	for _, tt := range tests {
+		f := DetectFormat([]byte(tt.data))
+		if f != tt.want {
+			t.Errorf("%q = %+v, want %+v", tt.data, f, tt.want)
+		}
+		if f.String() != tt.shown {
+			t.Errorf("%q shown as %q, want %q", tt.data, f.String(), tt.shown)
+		}
+	}
+}
+
+func TestSetFormat(t *testing.T) {
+	tests := []struct {
+		name   string
+		format Format
+		want   string
+	}{
+		{"LF", DefaultFormat, "a\nb\n"},
+		{"CRLF", Format{Encoding: UTF8, Ending: CRLF, FinalNewline: true}, "a\r\nb\r\n"},
+		{"CR", Format{Encoding: UTF8, Ending: CR}, "a\rb"},
+		{"UTF-8 BOM", Format{Encoding: UTF8, BOM: true}, "\xef\xbb\xbfa\nb"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := NewBuffer("a", "b")
+			b.SetFormat(tt.format)
+			if got := write(t, b); !bytes.Equal(got, []byte(tt.want)) {
+				t.Errorf("wrote %q, want %q", got, tt.want)
+			}
+			if b.Format().Mixed {
+				t.Error("mixed after SetFormat")
+			}
+		})
+	}
+}
+
+func TestSetFormatModified(t *testing.T) {
+	b := NewBuffer("a")
+	b.SetFormat(DefaultFormat)
+	if b.Modified() {
+		t.Error("modified by the same Format")
+	}
+	b.SetFormat(Format{Encoding: UTF8, Ending: CRLF})
+	if !b.Modified() {
+		t.Error("not modified by a new Format")
+	}
+}
@@ -0,0 +1,126 @@
+package buffer
+
+/*
+
+  File:    history.go
+  Author:  Bob Shofner
+
+  MIT License - https://opensource.org/license/mit/
+
+  This permission notice shall be included in all copies
+    or substantial portions of the Software.
+
+*/
+/*
+  Description: history provides multi-level undo and redo for a Buffer.
+	Every change is an edit (lines deleted and inserted at a line).
+	Edits between Begin and End are undone and redone as a single step.
+*/
+
+const DefaultUndoDepth = 100
+
+// edit replaces the deleted lines at line with the inserted lines
+type edit struct {
+	line     int
+	deleted  []string
+	inserted []string
+}
+
+type history struct {
+	undo  [][]edit
+	redo  [][]edit
+	group []edit
+	level int
+	depth int
+}
+
+// apply makes the edit and records it
+func (b *Buffer) apply(e edit) {
+	b.record(e)
+	b.replaceLines(e.line, len(e.deleted), e.inserted)
+}
+
+// record saves an edit, as a step of its own or as part of the open group
+func (b *Buffer) record(e edit) {
+	b.redo = nil
+	if b.level > 0 {
+		b.group = append(b.group, e)
+		return
+	}
+	b.push([]edit{e})
+}
+
+func (b *Buffer) push(step []edit) {
+	b.undo = append(b.undo, step)
+	if b.depth > 0 && len(b.undo) > b.depth {
+		b.undo = b.undo[len(b.undo)-b.depth:]
+	}
+}
+
+// Begin starts a group of changes that are undone as one. Groups may nest.
+func (b *Buffer) Begin() {
+	b.level++
+}
+
+// End closes the group started by Begin
+func (b *Buffer) End() {
+	if b.level == 0 {
+		return
+	}
+	b.level--
+	if b.level == 0 && len(b.group) > 0 {
+		b.push(b.group)
+		b.group = nil
+	}
+}
+
+// SetUndoDepth sets the number of undo steps kept (0 is unlimited)
+func (b *Buffer) SetUndoDepth(depth int) {
+	b.depth = max(depth, 0)
+	if b.depth > 0 && len(b.undo) > b.depth {
+		b.undo = b.undo[len(b.undo)-b.depth:]
+	}
+}
+
+// CanUndo reports whether there is a change to undo
+func (b *Buffer) CanUndo() bool {
+	return len(b.undo) > 0
+}
+
+// CanRedo reports whether there is an undone change to redo
+func (b *Buffer) CanRedo() bool {
+	return len(b.redo) > 0
+}
+
+// Undo reverts the last change. It returns the first line changed.
+func (b *Buffer) Undo() (line int, ok bool) {
+	if len(b.undo) == 0 || b.level > 0 {
+		return 0, false
+	}
+	step := b.undo[len(b.undo)-1]
+	b.undo = b.undo[:len(b.undo)-1]
+	for i := len(step) - 1; i >= 0; i-- {
+		e := step[i]
+		b.replaceLines(e.line, len(e.inserted), e.deleted)
+		line = e.line
+	}
+	b.redo = append(b.redo, step)
+	return line, true
+}
+
+// Redo makes the last undone change again. It returns the first line changed.
+func (b *Buffer) Redo() (line int, ok bool) {
+	if len(b.redo) == 0 || b.level > 0 {
+		return 0, false
+	}
+	step := b.redo[len(b.redo)-1]
+	b.redo = b.redo[:len(b.redo)-1]
+	for i, e := range step {
+		b.replaceLines(e.line, len(e.deleted), e.inserted)
+		if i == 0 {
+			line = e.line
+		}
+	}
+	b.undo = append(b.undo, step)
+	return line, true
+}
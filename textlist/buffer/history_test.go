@@ -0,0 +1,91 @@
+package buffer
+
+import (
+	"testing"
+)
+
+/*
+
+  File:    history_test.go
+  Author:  Bob Shofner
+
+  MIT License - https://opensource.org/license/mit/
+
+  This permission notice shall be included in all copies
+    or substantial portions of the Software.
+
+*/
+/*
+  Description: tests of undo and redo, and their groups.
+*/
+
+func TestBufferUndo(t *testing.T) {
+	b := NewBuffer("a", "b", "c")
+	b.Replace(0, "x")
+	b.Begin()
+	b.Delete(1, 1)
+	b.Begin() // groups nest
+	b.Insert(0, "y")
+	b.End()
+	if _, ok := b.Undo(); ok {
+		t.Error("undo in an open group")
+	}
+	b.End()
+
+	steps := []struct {
+		undo bool
+		line int
+		want string
+	}{
+		{true, 1, "x\nb\nc"}, // the group, as one step
+		{true, 0, "a\nb\nc"},
+		{false, 0, "x\nb\nc"},
+		{false, 1, "y\nx\nc"},
+	}
+	for i, s := range steps {
+		do := b.Redo
+		if s.undo {
+			do = b.Undo
+		}
+		line, ok := do()
+		if !ok || line != s.line || b.String() != s.want {
+			t.Errorf("step %d: line %d, %v, %q; want line %d, %q", i, line, ok, b.String(), s.line, s.want)
+		}
+	}
+	if b.CanRedo() {
+		t.Error("redo left")
+	}
+
+	b.Undo()
+	b.Replace(0, "z") // a change drops the redo steps
+	if b.CanRedo() {
+		t.Error("redo after a change")
+	}
+}
+
+func TestBufferUndoDepth(t *testing.T) {
+	b := NewBuffer("a")
+	b.SetUndoDepth(2)
+	for _, s := range []string{"b", "c", "d"} {
+		b.Replace(0, s)
+	}
+	b.Undo()
+	b.Undo()
+	if _, ok := b.Undo(); ok || b.Line(0) != "b" {
+		t.Errorf("line %q, want b and no more undo", b.Line(0))
+	}
+}
+
+func TestBufferUndoEmptyGroup(t *testing.T) {
+	b := NewBuffer("a")
+	b.Replace(0, "b")
+	b.Begin()
+	b.End()
+	if line, ok := b.Undo(); !ok || line != 0 || b.Line(0) != "a" {
+		t.Errorf("an empty group was an undo step: %q", b.Line(0))
+	}
+	b.End() // no group is open
+	if b.CanUndo() {
+		t.Error("undo left")
+	}
+}
@@ -0,0 +1,181 @@
+package buffer
+
+import (
+	"bytes"
+	"slices"
+	"sort"
+)
+
+/*
+
+  File:    piecetable.go
+  Author:  Bob Shofner
+
+  MIT License - https://opensource.org/license/mit/
+
+  This permission notice shall be included in all copies
+    or substantial portions of the Software.
+
+*/
+/*
+  Description: pieceTable is the line storage of a Buffer.
+	The original (file) bytes are kept as read, with only the offset
+	of each line end, and the lines are materialised when asked for.
+	Added lines are appended to the add list. The pieces are runs of
+	lines, from either source, in Buffer order.
+	Memory stays close to the file size, and an edit touches only the
+	pieces it splits, not the lines after it.
+*/
+
+// piece is a run of count lines, starting at line start of its source
+type piece struct {
+	add   bool
+	start int
+	count int
+}
+
+type pieceTable struct {
+	data   []byte // original content
+	sep    byte   // original line separator, \n (LF and CRLF) or \r (CR)
+	ends   []int  // offset of the end of each original line
+	add    []string
+	pieces []piece
+	starts []int // the Buffer line of the first line of each piece
+	length int
+}
+
+// snapshot copies the table. The data and add lines are shared, as they are never changed,
+// only appended to (past the end of the snapshot).
+func (t *pieceTable) snapshot() *pieceTable {
+	c := *t
+	c.add = t.add[:len(t.add):len(t.add)]
+	c.pieces = slices.Clone(t.pieces)
+	c.starts = slices.Clone(t.starts)
+	return &c
+}
+
+// newPieceTable creates a table on the original content (lines separated by sep).
+// A final sep ends the last line, it does not start an empty one.
+func newPieceTable(data []byte, sep byte) *pieceTable {
+	t := &pieceTable{data: data, sep: sep}
+	for off := 0; off < len(data); {
+		ix := bytes.IndexByte(data[off:], sep)
+		if ix < 0 {
+			t.ends = append(t.ends, len(data))
+			break
+		}
+		t.ends = append(t.ends, off+ix)
+		off += ix + 1
+	}
+	if len(t.ends) > 0 {
+		t.pieces = []piece{{start: 0, count: len(t.ends)}}
+	}
+	t.index()
+	return t
+}
+
+// index recomputes the first line of each piece, and the length
+func (t *pieceTable) index() {
+	t.starts = t.starts[:0]
+	t.length = 0
+	for _, p := range t.pieces {
+		t.starts = append(t.starts, t.length)
+		t.length += p.count
+	}
+}
+
+// line materialises line n
+func (t *pieceTable) line(n int) string {
+	i := sort.Search(len(t.starts), func(i int) bool { return t.starts[i] > n }) - 1
+	p := t.pieces[i]
+	ix := p.start + n - t.starts[i]
+	if p.add {
+		return t.add[ix]
+	}
+	return t.original(ix)
+}
+
+// original returns line ix of the original content, without the line ending
+func (t *pieceTable) original(ix int) string {
+	start := 0
+	if ix > 0 {
+		start = t.ends[ix-1] + 1
+	}
+	end := t.ends[ix]
+	if t.sep == '\n' && end > start && t.data[end-1] == '\r' {
+		end--
+	}
+	return string(t.data[start:end])
+}
+
+// each calls f for the lines from n onward, until f returns false
+func (t *pieceTable) each(n int, f func(n int, line string) bool) {
+	if n >= t.length {
+		return
+	}
+	i := sort.Search(len(t.starts), func(i int) bool { return t.starts[i] > n }) - 1
+	for ; i < len(t.pieces); i++ {
+		p := t.pieces[i]
+		for ix := p.start + max(n-t.starts[i], 0); ix < p.start+p.count; ix++ {
+			line := ""
+			if p.add {
+				line = t.add[ix]
+			} else {
+				line = t.original(ix)
+			}
+			if !f(n, line) {
+				return
+			}
+			n++
+		}
+	}
+}
+
+// split makes a piece boundary at line n, and returns the index of the piece starting there
+func (t *pieceTable) split(n int) int {
+	if n >= t.length {
+		return len(t.pieces)
+	}
+	i := sort.Search(len(t.starts), func(i int) bool { return t.starts[i] > n }) - 1
+	off := n - t.starts[i]
+	if off == 0 {
+		return i
+	}
+	p := t.pieces[i]
+	head := piece{add: p.add, start: p.start, count: off}
+	tail := piece{add: p.add, start: p.start + off, count: p.count - off}
+	t.pieces = append(t.pieces[:i], append([]piece{head, tail}, t.pieces[i+1:]...)...)
+	t.index()
+	return i + 1
+}
+
+// replace removes n lines at line, and inserts lines
+func (t *pieceTable) replace(line, n int, lines []string) {
+	first := t.split(line)
+	last := t.split(line + n)
+	var insert []piece
+	if len(lines) > 0 {
+		insert = []piece{{add: true, start: len(t.add), count: len(lines)}}
+		t.add = append(t.add, lines...)
+	}
+	t.pieces = append(t.pieces[:first], append(insert, t.pieces[last:]...)...)
+	t.index()
+}
+
+// append adds lines to the end, extending the last piece when it can
+func (t *pieceTable) append(lines []string) {
+	if len(lines) == 0 {
+		return
+	}
+	if n := len(t.pieces); n > 0 {
+		if p := &t.pieces[n-1]; p.add && p.start+p.count == len(t.add) {
+			p.count += len(lines)
+			t.add = append(t.add, lines...)
+			t.length += len(lines)
+			return
+		}
+	}
+	t.pieces = append(t.pieces, piece{add: true, start: len(t.add), count: len(lines)})
+	t.add = append(t.add, lines...)
+	t.index()
+}
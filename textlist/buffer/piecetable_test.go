@@ -0,0 +1,112 @@
+package buffer
+
+import (
+	"slices"
+	"testing"
+)
+
+/*
+
+  File:    piecetable_test.go
+  Author:  Bob Shofner
+
+  MIT License - https://opensource.org/license/mit/
+
+  This permission notice shall be included in all copies
+    or substantial portions of the Software.
+
+*/
+/*
+  Description: tests of the pieceTable, against a plain slice of lines.
+*/
+
+// tableLines returns all the lines of t
+func tableLines(t *pieceTable) (lines []string) {
+	t.each(0, func(n int, line string) bool {
+		lines = append(lines, line)
+		return true
+	})
+	return
+}
+
+func TestPieceTableLines(t *testing.T) {
+	tests := []struct {
+		data string
+		sep  byte
+		want []string
+	}{
+		{"", '\n', nil},
+		{"a", '\n', []string{"a"}},
+		{"a\n", '\n', []string{"a"}},
+		{"a\n\n", '\n', []string{"a", ""}},
+		{"a\r\nb", '\n', []string{"a", "b"}},
+		{"a\r\r\n", '\n', []string{"a\r"}},
+		{"a\rb\r", '\r', []string{"a", "b"}},
+	}
+	for _, tt := range tests {
+		table := newPieceTable([]byte(tt.data), tt.sep)
+		if got := tableLines(table); !slices.Equal(got, tt.want) || table.length != len(tt.want) {
+			t.Errorf("%q: %q (%d), want %q", tt.data, got, table.length, tt.want)
+		}
+		for n, want := range tt.want {
+			if got := table.line(n); got != want {
+				t.Errorf("%q: line %d %q, want %q", tt.data, n, got, want)
+			}
+		}
+	}
+}
+
+func TestPieceTableReplace(t *testing.T) {
+	steps := []struct {
+		line, n int
+		lines   []string
+	}{
+		{1, 0, []string{"x", "y"}}, // insert
+		{0, 1, nil},                // delete the first
+		{2, 2, []string{"z"}},      // replace across pieces
+		{4, 0, []string{"end"}},    // insert at the end
+		{0, 5, nil},                // delete all
+		{0, 0, []string{"new"}},
+	}
+	table := newPieceTable([]byte("a\nb\nc\nd\n"), '\n')
+	model := []string{"a", "b", "c", "d"}
+	for i, s := range steps {
+		table.replace(s.line, s.n, s.lines)
+		model = slices.Replace(model, s.line, s.line+s.n, s.lines...)
+		if got := tableLines(table); !slices.Equal(got, model) || table.length != len(model) {
+			t.Fatalf("step %d: %q (%d), want %q", i, got, table.length, model)
+		}
+	}
+}
+
+func TestPieceTableAppend(t *testing.T) {
+	table := newPieceTable([]byte("a\n"), '\n')
+	table.append([]string{"b"})
+	table.append([]string{"c", "d"}) // extends the last piece
+	table.append(nil)
+	if got := tableLines(table); !slices.Equal(got, []string{"a", "b", "c", "d"}) || len(table.pieces) != 2 {
+		t.Errorf("%q in %d pieces", got, len(table.pieces))
+	}
+	table.replace(1, 1, nil)
+	table.append([]string{"e"})
+	if got := tableLines(table); !slices.Equal(got, []string{"a", "c", "d", "e"}) {
+		t.Errorf("%q", got)
+	}
+}
+
+func TestPieceTableEach(t *testing.T) {
+	table := newPieceTable([]byte("a\nb\nc\n"), '\n')
+	table.replace(1, 1, []string{"x"})
+	var got []string
+	table.each(1, func(n int, line string) bool {
+		got = append(got, line)
+		return n < 1 // stop after the first
+	})
+	if !slices.Equal(got, []string{"x"}) {
+		t.Errorf("%q", got)
+	}
+	table.each(3, func(int, string) bool {
+		t.Error("called past the end")
+		return true
+	})
+}
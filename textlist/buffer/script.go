@@ -0,0 +1,239 @@
+package buffer
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+/*
+
+  File:    script.go
+  Author:  Bob Shofner
+
+  MIT License - https://opensource.org/license/mit/
+
+  This permission notice shall be included in all copies
+    or substantial portions of the Software.
+
+*/
+/*
+  Description: Script runs EDLIN commands against a file without a window.
+	The commands are those of the TextList command mode, plus:
+
+	[line]          the next script line replaces line (empty keeps it)
+	[line]I         the following script lines are inserted, until "."
+	W[filename]     write the lines (to filename)
+	E               write the lines and end the script
+	Q               end the script without writing
+
+	Empty lines and lines starting with ';' are ignored.
+	The end of the script is the same as Q.
+*/
+
+var errQuit = errors.New("quit")
+
+// Script is a headless EDLIN session on a single file
+type Script struct {
+	Path string
+	Out  io.Writer
+
+	buffer      *Buffer
+	rowId       int
+	lastSearch  string
+	lastReplace string
+}
+
+// NewScript loads path. A file that does not exist starts empty (a new file).
+func NewScript(path string, out io.Writer) (*Script, error) {
+	s := &Script{
+		Path:   path,
+		Out:    out,
+		buffer: NewBuffer(),
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	} else if err != nil {
+		return nil, err
+	}
+	s.buffer = NewBufferBytes(data)
+	return s, nil
+}
+
+// Run executes the commands read from r. The error names the failing script line.
+// Script lines may be of any length.
+func (s *Script) Run(r io.Reader) error {
+	reader := bufio.NewReader(r)
+	lineNo := 0
+	var readErr error
+	next := func() (string, bool) {
+		str, err := reader.ReadString('\n')
+		if err != nil && str == "" {
+			if err != io.EOF {
+				readErr = err
+			}
+			return "", false
+		}
+		lineNo++
+		str = strings.TrimSuffix(str, "\n")
+		return strings.TrimSuffix(str, "\r"), true
+	}
+
+	for {
+		str, ok := next()
+		if !ok {
+			break
+		}
+		if strings.TrimSpace(str) == "" || strings.HasPrefix(str, ";") {
+			continue
+		}
+		err := s.exec(str, next)
+		if errors.Is(err, errQuit) {
+			return nil
+		} else if err != nil {
+			return fmt.Errorf("script line %d: %s: %w", lineNo, str, err)
+		}
+	}
+	return readErr
+}
+
+// Buffer returns the Buffer being edited
+func (s *Script) Buffer() *Buffer {
+	return s.buffer
+}
+
+// exec runs a single command. next reads the following script lines, for edit and insert.
+func (s *Script) exec(str string, next func() (string, bool)) error {
+	current := s.rowId + 1
+	count := s.buffer.Len()
+	cmd, err := ParseCommand(str, current, count)
+	if err != nil {
+		return err
+	}
+	if cmd.Query {
+		return errors.New("? is not available in a script")
+	}
+
+	switch cmd.Op {
+	case 0:
+		rowId, err := EditLine(cmd, current, count)
+		if err != nil {
+			return err
+		}
+		s.rowId = rowId
+		if text, ok := next(); ok && text != "" {
+			s.buffer.Replace(rowId, text)
+		}
+
+	case 'L', 'P':
+		list := ListLines
+		if cmd.Op == 'P' {
+			list = PageLines
+		}
+		start, end, err := list(cmd, current, count)
+		if err != nil {
+			return err
+		}
+		for rowId := start; rowId <= end; rowId++ {
+			s.print(rowId)
+		}
+		if cmd.Op == 'P' && end >= start {
+			s.rowId = end
+		}
+
+	case 'I':
+		rowId, err := InsertLine(cmd, current)
+		if err != nil {
+			return err
+		}
+		for {
+			text, ok := next()
+			if !ok || text == "." {
+				break
+			}
+			s.buffer.InsertString(rowId, text)
+			rowId++
+		}
+		s.rowId = rowId
+
+	case 'D', 'C', 'M', 'T':
+		run := DeleteCommand
+		switch cmd.Op {
+		case 'C', 'M':
+			run = CopyMoveCommand
+		case 'T':
+			run = TransferCommand
+		}
+		rowId, err := run(s.buffer, cmd, current)
+		if err != nil {
+			return err
+		}
+		s.rowId = rowId
+
+	case 'S':
+		if cmd.Text != "" {
+			s.lastSearch = cmd.Text
+		}
+		if s.lastSearch == "" {
+			return ErrEntry
+		}
+		start, end := SearchRange(cmd, current, count)
+		rowId, _ := SearchLines(s.buffer, start, end, s.lastSearch)
+		if rowId < 0 {
+			return errors.New("Not found")
+		}
+		s.rowId = rowId
+		s.print(rowId)
+
+	case 'R':
+		if cmd.Text != "" {
+			s.lastSearch, s.lastReplace = SplitReplace(cmd.Text)
+		}
+		if s.lastSearch == "" {
+			return ErrEntry
+		}
+		start, end := SearchRange(cmd, current, count)
+		if _, last := ReplaceLines(s.buffer, start, end, s.lastSearch, s.lastReplace); last >= 0 {
+			s.rowId = last
+		}
+
+	case 'W':
+		name := strings.TrimSpace(cmd.Text)
+		if name == "" {
+			name = s.Path
+		}
+		return s.write(name)
+
+	case 'E':
+		if err := s.write(s.Path); err != nil {
+			return err
+		}
+		return errQuit
+
+	case 'Q':
+		return errQuit
+
+	default:
+		return ErrEntry
+	}
+	return nil
+}
+
+func (s *Script) print(rowId int) {
+	if s.Out == nil {
+		return
+	}
+	mark := ":"
+	if rowId == s.rowId {
+		mark = ":*"
+	}
+	_, _ = fmt.Fprintf(s.Out, "%8d%s%s\n", rowId+1, mark, s.buffer.Line(rowId))
+}
+
+func (s *Script) write(name string) error {
+	return s.buffer.WriteFile(name, false)
+}
@@ -0,0 +1,125 @@
+package buffer
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+/*
+
+  File:    script_test.go
+  Author:  Bob Shofner
+
+  MIT License - https://opensource.org/license/mit/
+
+  This permission notice shall be included in all copies
+    or substantial portions of the Software.
+
+*/
+/*
+  Description: tests of Script, each command run against a file in a temporary folder.
+*/
+
+func TestScript(t *testing.T) {
+	const file = "one\ntwo\nthree\n"
+	tests := []struct {
+		name   string
+		script string
+		want   string // the file afterward
+		out    string // printed by L, P and S
+		err    string // part of the Run error
+	}{
+		{"quit", "1D\nQ\n", file, "", ""},
+		{"end of script", "1D\n", file, "", ""},
+		{"end", "1D\nE\n2D\n", "two\nthree\n", "", ""},
+		{"write", "; a comment\n\n1D\nW\n2D\nQ\n", "two\nthree\n", "", ""},
+		{"edit", "2\nTWO\n3\n\nE\n", "one\nTWO\nthree\n", "", ""},
+		{"insert", "2I\na\nb\n.\nE\n", "one\na\nb\ntwo\nthree\n", "", ""},
+		{"insert at end", "#I\nfour\n.\nE\n", "one\ntwo\nthree\nfour\n", "", ""},
+		{"insert to end of script", "1I\nzero", file, "", ""},
+		{"delete", "2,3D\nE\n", "one\n", "", ""},
+		{"copy", "1,2,4C\nE\n", "one\ntwo\nthree\none\ntwo\n", "", ""},
+		{"move", "3,3,1M\nE\n", "three\none\ntwo\n", "", ""},
+		{"replace", "1,$Ro^ZO\nE\n", "One\ntwO\nthree\n", "", ""},
+		{"replace ctrl+z", "1,$Rt\x1aT\nE\n", "one\nTwo\nThree\n", "", ""},
+		{"replace again", "1Ro^Z0\n2,3R\nE\n", "0ne\ntw0\nthree\n", "", ""},
+		{"replace left to right", "1Rone^Zaaa\n1Raa^Zb\nE\n", "ba\ntwo\nthree\n", "", ""},
+		{"list", "L\n", file, "       1:*one\n       2:two\n       3:three\n", ""},
+		{"page", "2,3P\n1,3L\n", file, "       2:two\n       3:three\n       1:one\n       2:two\n       3:*three\n", ""},
+		{"search", "1St\nS\n", file, "       2:*two\n       3:*three\n", ""},
+		{"not found", "Sx\n", file, "", "script line 1: Sx: Not found"},
+		{"query", "?Sone\n", file, "", "script line 1"},
+		{"entry error", "1D\n9,1D\n", file, "", "script line 2: 9,1D: Entry error"},
+		{"unknown", "X\n", file, "", "Entry error"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "file.txt")
+			if err := os.WriteFile(path, []byte(file), 0600); err != nil {
+				t.Fatal(err)
+			}
+			var out strings.Builder
+			s, err := NewScript(path, &out)
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = s.Run(strings.NewReader(tt.script))
+			if tt.err == "" && err != nil || tt.err != "" && (err == nil || !strings.Contains(err.Error(), tt.err)) {
+				t.Errorf("error %v, want %q", err, tt.err)
+			}
+			data, _ := os.ReadFile(path)
+			if string(data) != tt.want {
+				t.Errorf("file %q, want %q", data, tt.want)
+			}
+			if out.String() != tt.out {
+				t.Errorf("out %q, want %q", out.String(), tt.out)
+			}
+		})
+	}
+}
+
+func TestScriptNewFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "new.txt")
+	s, err := NewScript(path, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	copied := filepath.Join(dir, "copy.txt")
+	if err = s.Run(strings.NewReader("L\nP\nI\nfirst\n.\nW" + copied + "\nE\n")); err != nil {
+		t.Fatal(err)
+	}
+	data, _ := os.ReadFile(path)
+	if string(data) != "first\n" || s.Buffer().Len() != 1 {
+		t.Errorf("file %q", data)
+	}
+	if data, _ = os.ReadFile(copied); string(data) != "first\n" {
+		t.Errorf("W wrote %q", data)
+	}
+	if _, err = NewScript(dir, nil); err == nil {
+		t.Error("a folder read as a file")
+	}
+}
+
+func TestScriptTransfer(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "file.txt")
+	other := filepath.Join(dir, "other.txt")
+	if err := os.WriteFile(other, []byte("x\r\ny\r\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	s, _ := NewScript(path, nil)
+	s.Buffer().Append("a", "b")
+	err := s.Run(strings.NewReader("2T" + other + "\nE\n"))
+	data, _ := os.ReadFile(path)
+	if err != nil || string(data) != "a\nx\ny\nb\n" {
+		t.Errorf("file %q, %v", data, err)
+	}
+	err = s.Run(strings.NewReader("T" + filepath.Join(dir, "missing") + "\n"))
+	if !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("error %v, want not exist", err)
+	}
+}
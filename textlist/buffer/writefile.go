@@ -0,0 +1,141 @@
+package buffer
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"math/rand/v2"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+/*
+
+  File:    writefile.go
+  Author:  Bob Shofner
+
+  MIT License - https://opensource.org/license/mit/
+
+  This permission notice shall be included in all copies
+    or substantial portions of the Software.
+
+*/
+/*
+  Description: WriteFile saves a Buffer without risk to the file.
+	The lines are written to a temporary file in the same folder,
+	synced to disk, and renamed over the file. A crash or a full disk
+	leaves the file as it was. As with the original EDLIN, the
+	previous version may be kept as name.BAK.
+*/
+
+// WriteFile writes the Buffer to path, keeping the permissions (and owner) of
+// an existing file. With backup, the previous content is kept in BackupPath(path).
+func (b *Buffer) WriteFile(path string, backup bool) error {
+	if target, err := filepath.EvalSymlinks(path); err == nil {
+		path = target // replace the file, not the link
+	}
+	info, err := os.Stat(path)
+	exists := err == nil
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+
+	tmp, err := createTemp(path)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = os.Remove(tmp.Name()) // gone, once renamed
+	}()
+	if exists {
+		if err = tmp.Chmod(info.Mode().Perm()); err == nil {
+			chown(tmp, info)
+		}
+	}
+	if err == nil {
+		_, err = b.WriteTo(tmp)
+	}
+	if err == nil {
+		err = tmp.Sync()
+	}
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return err
+	}
+
+	if exists && backup {
+		if err = keepBackup(path); err != nil {
+			return fmt.Errorf("backup: %w", err)
+		}
+	}
+	if err = os.Rename(tmp.Name(), path); err != nil {
+		return err
+	}
+	syncDir(filepath.Dir(path))
+	return nil
+}
+
+// BackupPath is the EDLIN backup of a file: name.txt is kept as name.bak
+func BackupPath(path string) string {
+	ext := filepath.Ext(path)
+	if strings.EqualFold(ext, ".bak") {
+		return path + ".bak"
+	}
+	return strings.TrimSuffix(path, ext) + ".bak"
+}
+
+// createTemp creates an empty file beside path. A new file gets the default
+// permissions (less the umask), as os.Create would give it.
+func createTemp(path string) (*os.File, error) {
+	dir, name := filepath.Split(path)
+	for range 100 {
+		tmp := filepath.Join(dir, fmt.Sprintf(".%s.%08x.tmp", name, rand.Uint32()))
+		f, err := os.OpenFile(tmp, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0666)
+		if !errors.Is(err, fs.ErrExist) {
+			return f, err
+		}
+	}
+	return nil, fmt.Errorf("%s: can not create a temporary file", name)
+}
+
+// keepBackup links (or copies) the current file to its backup
+func keepBackup(path string) error {
+	bak := BackupPath(path)
+	if err := os.Remove(bak); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	if os.Link(path, bak) == nil {
+		return nil
+	}
+	return copyFile(path, bak)
+}
+
+func copyFile(from, to string) error {
+	in, err := os.Open(from)
+	if err != nil {
+		return err
+	}
+	defer func(in *os.File) {
+		_ = in.Close()
+	}(in)
+	info, err := in.Stat()
+	if err != nil {
+		return err
+	}
+	out, err := os.OpenFile(to, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, info.Mode().Perm())
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(out, in)
+	if err == nil {
+		err = out.Sync()
+	}
+	if cerr := out.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
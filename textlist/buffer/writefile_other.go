@@ -0,0 +1,27 @@
+//go:build !unix
+
+package buffer
+
+import (
+	"io/fs"
+	"os"
+)
+
+/*
+
+  File:    writefile_other.go
+  Author:  Bob Shofner
+
+  MIT License - https://opensource.org/license/mit/
+
+  This permission notice shall be included in all copies
+    or substantial portions of the Software.
+
+*/
+/*
+  Description: systems without unix file ownership, or directory sync.
+*/
+
+func chown(*os.File, fs.FileInfo) {}
+
+func syncDir(string) {}
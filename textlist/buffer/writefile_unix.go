@@ -0,0 +1,40 @@
+//go:build unix
+
+package buffer
+
+import (
+	"io/fs"
+	"os"
+	"syscall"
+)
+
+/*
+
+  File:    writefile_unix.go
+  Author:  Bob Shofner
+
+  MIT License - https://opensource.org/license/mit/
+
+  This permission notice shall be included in all copies
+    or substantial portions of the Software.
+
+*/
+/*
+  Description: file ownership and directory sync for unix systems.
+*/
+
+// chown gives f the owner of the file it replaces. Only root may give a
+// file away, so a failure leaves f owned by the user.
+func chown(f *os.File, info fs.FileInfo) {
+	if st, ok := info.Sys().(*syscall.Stat_t); ok {
+		_ = f.Chown(int(st.Uid), int(st.Gid))
+	}
+}
+
+// syncDir makes a rename in dir durable
+func syncDir(dir string) {
+	if d, err := os.Open(dir); err == nil {
+		_ = d.Sync()
+		_ = d.Close()
+	}
+}
@@ -0,0 +1,309 @@
+package textlist
+
+import (
+	"edlin/textlist/buffer"
+	"errors"
+	"fmt"
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+/*
+
+  File:    commandview.go
+  Author:  Bob Shofner
+
+  MIT License - https://opensource.org/license/mit/
+
+  This permission notice shall be included in all copies
+    or substantial portions of the Software.
+
+*/
+/*
+   Description: commandView manages the view for entering EDLIN line commands.
+*/
+
+var _ buffer.LineEditor = rowEditor{}
+
+var errReadOnly = errors.New("Read only")
+
+// showCommand brings the EDLIN command view to the front
+func (l *TextList) showCommand() {
+	l.mode = modeCommand
+	l.editBox.Hide()
+	l.searchBox.Hide()
+	l.commandBox.Show()
+	l.inserting = false
+	l.command.SetText("")
+	l.setPrompt()
+	l.UnselectAll()
+	l.focus(l.command)
+}
+
+// commandView creates a container with a command Entry and optional control Buttons (callbacks)
+func (l *TextList) commandView(buttonBar *fyne.Container) {
+
+	l.command = widget.NewEntry()
+	l.command.PlaceHolder = "<command>"
+	l.prompt = widget.NewLabel("*")
+	l.prompt.TextStyle = l.Theme.style
+	cancel := widget.NewButtonWithIcon("", theme.CancelIcon(), nil)
+
+	l.command.OnSubmitted = func(str string) {
+		l.command.SetText("")
+		if l.inserting {
+			l.insertLine(str)
+			return
+		}
+		if err := l.runCommand(str); err != nil {
+			l.toast(err.Error(), failColor, 500*time.Millisecond)
+		}
+		if l.mode == modeCommand {
+			l.setPrompt()
+			l.focus(l.command)
+		}
+	}
+
+	cancel.OnTapped = func() {
+		if l.inserting {
+			l.inserting = false
+			l.setPrompt()
+			return
+		}
+		l.showEdit()
+	}
+
+	if buttonBar != nil {
+		sep := canvas.NewLine(l.Theme.Color("normalColor", 0))
+		l.commandBox = container.NewBorder(sep, buttonBar, l.prompt, cancel, l.command)
+	} else {
+		l.commandBox = container.NewBorder(nil, nil, l.prompt, cancel, l.command)
+	}
+}
+
+// insertLine inserts the entry before insertAt. A single "." ends insert mode.
+func (l *TextList) insertLine(str string) {
+	if str == "." {
+		l.inserting = false
+		l.moveToRow(min(l.insertAt, l.buffer.Len()))
+		l.setPrompt()
+		return
+	}
+	l.rowId = l.insertAt
+	l.insertRows(str)
+	l.insertAt += strings.Count(str, "\n") + 1
+	l.rowId = l.insertAt
+	l.ScrollTo(l.insertAt)
+	l.setPrompt()
+}
+
+func (l *TextList) setPrompt() {
+	if l.inserting {
+		l.prompt.SetText(fmt.Sprintf("%d:*", l.insertAt+1))
+	} else {
+		l.prompt.SetText(fmt.Sprintf("%d*", l.rowId+1))
+	}
+}
+
+// runCommand parses and executes a single EDLIN command against the rows
+func (l *TextList) runCommand(str string) error {
+	current := l.rowId + 1
+	count := l.buffer.Len()
+	cmd, err := buffer.ParseCommand(str, current, count)
+	if err != nil {
+		return err
+	}
+	if l.readOnly && strings.ContainsRune("IDCMTR", cmd.Op) {
+		return errReadOnly
+	}
+	l.buffer.Begin() // a command is undone as a single step
+	defer l.buffer.End()
+
+	switch cmd.Op {
+	case 0:
+		rowId, err := buffer.EditLine(cmd, current, count)
+		if err != nil {
+			return err
+		}
+		l.showEdit()
+		l.moveToRow(rowId)
+		l.focus(l.edit)
+
+	case 'L':
+		start, end, err := buffer.ListLines(cmd, current, count)
+		if err != nil || end < start {
+			return err
+		}
+		l.markRows(start, end)
+		l.ScrollTo(start)
+
+	case 'P':
+		start, end, err := buffer.PageLines(cmd, current, count)
+		if err != nil || end < start {
+			return err
+		}
+		l.moveToRow(end)
+		l.markRows(start, end)
+		l.ScrollTo(start)
+
+	case 'I':
+		rowId, err := buffer.InsertLine(cmd, current)
+		if err != nil {
+			return err
+		}
+		l.inserting = true
+		l.insertAt = rowId
+		l.ScrollTo(rowId)
+
+	case 'D', 'C', 'M', 'T':
+		l.clearMarkedRows()
+		run := buffer.DeleteCommand
+		switch cmd.Op {
+		case 'C', 'M':
+			run = buffer.CopyMoveCommand
+		case 'T':
+			run = buffer.TransferCommand
+		}
+		rowId, err := run(rowEditor{l}, cmd, current)
+		if err != nil {
+			return err
+		}
+		l.moveToRow(min(rowId, l.buffer.Len()))
+
+	case 'S':
+		if cmd.Text != "" {
+			l.lastSearch = cmd.Text
+		}
+		if l.lastSearch == "" {
+			return buffer.ErrEntry
+		}
+		start, end := buffer.SearchRange(cmd, current, count)
+		l.searchRows(start, end, l.lastSearch, cmd.Query)
+
+	case 'R':
+		if cmd.Text != "" {
+			l.lastSearch, l.lastReplace = buffer.SplitReplace(cmd.Text)
+		}
+		if l.lastSearch == "" {
+			return buffer.ErrEntry
+		}
+		start, end := buffer.SearchRange(cmd, current, count)
+		l.clearMarkedRows()
+		if cmd.Query {
+			l.buffer.Begin() // ended by confirmReplace, after the last O.K.?
+			l.confirmReplace(start, end, 0, 0)
+		} else {
+			n, last := buffer.ReplaceLines(rowEditor{l}, start, end, l.lastSearch, l.lastReplace)
+			if last >= 0 {
+				l.moveToRow(last)
+			}
+			l.toast(fmt.Sprintf("%d replaced", n), infoColor, 500*time.Millisecond)
+		}
+
+	default:
+		return fmt.Errorf("<%c> not available in a tab", cmd.Op)
+	}
+
+	l.Refresh()
+	return nil
+}
+
+// rowEditor runs the EDLIN commands through the TextList row machinery
+type rowEditor struct {
+	l *TextList
+}
+
+func (e rowEditor) Len() int {
+	return e.l.buffer.Len()
+}
+
+func (e rowEditor) Line(rowId int) string {
+	return e.l.getRowString(rowId)
+}
+
+func (e rowEditor) InsertString(rowId int, content string) {
+	e.l.rowId = rowId
+	e.l.insertRows(content)
+}
+
+func (e rowEditor) Delete(start, end int) []string {
+	lines := e.l.buffer.Lines(start, end)
+	for range lines {
+		e.l.deleteRow(start)
+	}
+	return lines
+}
+
+func (e rowEditor) Splice(rowId, col1, col2 int, text string) {
+	e.l.replaceCells(rowId, col1, col2, []rune(text), true)
+}
+
+// markRows marks all rows between start and end (inclusive)
+func (l *TextList) markRows(start, end int) {
+	l.clearMarkedRows()
+	l.startMark = start
+	l.endMark = end
+	l.buffer.MarkLines(start, end)
+}
+
+// searchRows makes the first row (start..end) containing find the current row
+func (l *TextList) searchRows(start, end int, find string, query bool) {
+	rowId, col := buffer.SearchLines(rowEditor{l}, start, end, find)
+	if rowId < 0 {
+		l.toast("Not found", infoColor, 500*time.Millisecond)
+		return
+	}
+	l.clearMarkedRows()
+	l.markCells(result{
+		rowId: rowId,
+		col1:  col,
+		col2:  col + utf8.RuneCountInString(find) - 1,
+	})
+	l.moveToRow(rowId)
+	if query {
+		dialog.ShowConfirm("EDLIN", fmt.Sprintf("%d: %s\nO.K.?", rowId+1, l.getRowString(rowId)),
+			func(ok bool) {
+				if !ok {
+					l.searchRows(rowId+1, end, find, query)
+				}
+			}, l.window)
+	}
+}
+
+// confirmReplace asks O.K.? for each lastSearch in the rows start..end, starting at rowId, col.
+// The replacements are a single undo step: the caller begins it, and it ends with the last answer.
+func (l *TextList) confirmReplace(rowId, end, col, n int) {
+	for ; rowId <= end; rowId, col = rowId+1, 0 {
+		runes := l.getRowRunes(rowId)
+		ix := strings.Index(string(runes[col:]), l.lastSearch)
+		if ix < 0 {
+			continue
+		}
+		col1 := col + utf8.RuneCountInString(string(runes[col:])[:ix])
+		col2 := col1 + utf8.RuneCountInString(l.lastSearch) - 1
+		repl := []rune(l.lastReplace)
+		preview := string(runes[:col1]) + l.lastReplace + string(runes[col2+1:])
+		l.moveToRow(rowId)
+		dialog.ShowConfirm("EDLIN", fmt.Sprintf("%d: %s\nO.K.?", rowId+1, preview),
+			func(ok bool) {
+				next := col1 + 1
+				if ok {
+					l.replaceCells(rowId, col1, col2, repl, true)
+					next = col1 + len(repl)
+					n++
+				}
+				l.Refresh()
+				l.confirmReplace(rowId, end, next, n)
+			}, l.window)
+		return
+	}
+	l.buffer.End()
+	l.toast(fmt.Sprintf("%d replaced", n), infoColor, 500*time.Millisecond)
+}
@@ -28,6 +28,7 @@ import (
 func (l *TextList) showEdit() {
 	l.mode = modeEdit
 	l.searchBox.Hide()
+	l.commandBox.Hide()
 	l.edit.SetText("")
 	l.editBox.Show()
 	l.UnselectAll()
@@ -0,0 +1,57 @@
+package textlist
+
+import (
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/widget"
+)
+
+/*
+
+  File:    header.go
+  Author:  Bob Shofner
+
+  MIT License - https://opensource.org/license/mit/
+
+  This permission notice shall be included in all copies
+    or substantial portions of the Software.
+
+*/
+/*
+  Description: nameLabel is the name (file path) above the list.
+	Dragging it is passed to the TextList OnNameDragged and OnNameDragEnd,
+	so a container (such as DocTabs) may move the TextList. A right click
+	is passed to OnNameTappedSecondary, for a menu.
+*/
+
+type nameLabel struct {
+	widget.Label
+	list *TextList
+}
+
+func newNameLabel(l *TextList, name string) *nameLabel {
+	n := &nameLabel{list: l}
+	n.Text = name
+	n.Alignment = fyne.TextAlignTrailing
+	n.ExtendBaseWidget(n)
+	return n
+}
+
+// Dragged reports the sideways movement
+func (n *nameLabel) Dragged(e *fyne.DragEvent) {
+	if n.list.OnNameDragged != nil {
+		n.list.OnNameDragged(e.Dragged.DX, e.Dragged.DY)
+	}
+}
+
+func (n *nameLabel) DragEnd() {
+	if n.list.OnNameDragEnd != nil {
+		n.list.OnNameDragEnd()
+	}
+}
+
+// TappedSecondary reports a right click, at its canvas position
+func (n *nameLabel) TappedSecondary(e *fyne.PointEvent) {
+	if n.list.OnNameTappedSecondary != nil {
+		n.list.OnNameTappedSecondary(e.AbsolutePosition)
+	}
+}
@@ -0,0 +1,124 @@
+package textlist
+
+import (
+	"edlin/textlist/buffer"
+	"fmt"
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+	"slices"
+	"time"
+)
+
+/*
+
+  File:    replaceall.go
+  Author:  Bob Shofner
+
+  MIT License - https://opensource.org/license/mit/
+
+  This permission notice shall be included in all copies
+    or substantial portions of the Software.
+
+*/
+/*
+  Description: Replace All (or In Range, the rows marked when searching began).
+	Every match is listed with its line before and after the
+	replacement. The ticked matches are replaced as one undo step.
+*/
+
+// hit is a match to replace, with the new text
+type hit struct {
+	result
+	text    string
+	replace bool
+}
+
+// confirmReplaceAll previews the replacement of the matches, and replaces those left ticked
+func (l *TextList) confirmReplaceAll(inRange bool) {
+	if !l.editable() {
+		return
+	}
+	var hits []*hit
+	for _, r := range l.results {
+		if r.rowId < 0 || inRange && (r.rowId < l.rangeStart || r.rowId > l.rangeEnd) {
+			continue
+		}
+		hits = append(hits, &hit{result: r, text: l.replacement(r), replace: true})
+	}
+	if len(hits) == 0 {
+		l.toast("Nothing to Replace", infoColor, 500*time.Millisecond)
+		return
+	}
+	slices.SortFunc(hits, func(a, b *hit) int {
+		if a.rowId != b.rowId {
+			return a.rowId - b.rowId
+		}
+		return a.col1 - b.col1
+	})
+
+	list := widget.NewList(func() int {
+		return len(hits)
+	}, func() fyne.CanvasObject {
+		before := widget.NewLabel("")
+		after := widget.NewLabel("")
+		for _, label := range []*widget.Label{before, after} {
+			label.TextStyle = fyne.TextStyle{Monospace: true}
+			label.Truncation = fyne.TextTruncateEllipsis
+		}
+		return container.NewBorder(nil, nil, widget.NewCheck("", nil), nil, container.NewVBox(before, after))
+	}, func(id widget.ListItemID, o fyne.CanvasObject) {
+		h := hits[id]
+		row := o.(*fyne.Container)
+		lines := row.Objects[0].(*fyne.Container)
+		check := row.Objects[1].(*widget.Check)
+		line := []rune(l.buffer.Line(h.rowId))
+		col2 := min(h.col2+1, len(line))
+		check.OnChanged = nil
+		check.SetChecked(h.replace)
+		check.OnChanged = func(b bool) {
+			h.replace = b
+		}
+		lines.Objects[0].(*widget.Label).SetText(fmt.Sprintf("%d: %s", h.rowId+1, string(line)))
+		lines.Objects[1].(*widget.Label).SetText(fmt.Sprintf("%d: %s", h.rowId+1,
+			string(line[:min(h.col1, col2)])+h.text+string(line[col2:])))
+	})
+
+	title := fmt.Sprintf("Replace %d Matches", len(hits))
+	if inRange {
+		title += fmt.Sprintf(" in Lines %d-%d", l.rangeStart+1, l.rangeEnd+1)
+	}
+	d := dialog.NewCustomConfirm(title, "Replace", "Cancel", list, func(ok bool) {
+		if ok {
+			l.replaceHits(hits)
+		}
+	}, l.window)
+	d.Resize(fyne.NewSize(l.window.Canvas().Size().Width*0.9, l.window.Canvas().Size().Height*0.8))
+	d.Show()
+}
+
+// replacements returns the Replacement of each ticked hit
+func replacements(hits []*hit) (rs []buffer.Replacement) {
+	for _, h := range hits {
+		if h.replace {
+			rs = append(rs, buffer.Replacement{Match: buffer.Match{Line: h.rowId, Col1: h.col1, Col2: h.col2}, Text: h.text})
+		}
+	}
+	return rs
+}
+
+// replaceHits replaces the ticked hits as one undo step, ending the search
+func (l *TextList) replaceHits(hits []*hit) {
+	rs := replacements(hits)
+	if len(rs) == 0 {
+		return
+	}
+	lines := l.buffer.ReplaceAll(rs)
+	l.results = nil
+	l.clearMarkedRows()
+	l.disableReplace()
+	l.changed()
+	l.moveToRow(rs[0].Line)
+	l.toast(fmt.Sprintf("Replaced %d in %d lines", len(rs), lines), goColor, 2*time.Second)
+}
@@ -0,0 +1,45 @@
+package textlist
+
+import (
+	"edlin/textlist/buffer"
+	"testing"
+)
+
+/*
+
+  File:    replaceall_test.go
+  Author:  Bob Shofner
+
+  MIT License - https://opensource.org/license/mit/
+
+  This permission notice shall be included in all copies
+    or substantial portions of the Software.
+
+*/
+/*
+  Description: tests of Replace All, with hits left unticked in the preview.
+*/
+
+func TestReplacements(t *testing.T) {
+	b := buffer.NewBuffer("go go", "x", "go")
+	var hits []*hit
+	for _, m := range b.Search(0, "go", buffer.SearchOptions{}) {
+		hits = append(hits, &hit{result: result{rowId: m.Line, col1: m.Col1, col2: m.Col2}, text: "Go!", replace: true})
+	}
+	hits[1].replace = false // unticked
+	rs := replacements(hits)
+	if len(rs) != 2 {
+		t.Fatalf("replacements %v, want the 2 ticked", rs)
+	}
+	if lines := b.ReplaceAll(rs); lines != 2 || b.String() != "Go! go\nx\nGo!" {
+		t.Errorf("%d lines, %q", lines, b.String())
+	}
+	b.Undo()
+	if b.String() != "go go\nx\ngo" || b.CanUndo() {
+		t.Errorf("undo %q, want one step", b.String())
+	}
+	hits[0].replace, hits[2].replace = false, false
+	if rs = replacements(hits); rs != nil {
+		t.Errorf("replacements %v of none ticked", rs)
+	}
+}
@@ -0,0 +1,153 @@
+package textlist
+
+import (
+	"edlin/textlist/buffer"
+	"fmt"
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+	"time"
+)
+
+/*
+
+  File:    resultsview.go
+  Author:  Bob Shofner
+
+  MIT License - https://opensource.org/license/mit/
+
+  This permission notice shall be included in all copies
+    or substantial portions of the Software.
+
+*/
+/*
+  Description: the search results pane, beside the list.
+	It lists each match of the last search as "line: text", with the
+	match highlighted. Tapping a match moves to its row. The matches
+	are found again as the lines change, and may be exported (with
+	OnExportResults) to a new tab.
+*/
+
+// resultContext is the number of runes shown before a match
+const resultContext = 30
+
+// resultsView creates the (hidden) results pane
+func (l *TextList) resultsView() {
+	l.resultsLabel = widget.NewLabel("")
+	l.resultsList = widget.NewList(func() int {
+		return len(l.hits)
+	}, func() fyne.CanvasObject {
+		text := widget.NewRichText()
+		text.Truncation = fyne.TextTruncateEllipsis
+		return text
+	}, func(id widget.ListItemID, o fyne.CanvasObject) {
+		text := o.(*widget.RichText)
+		text.Segments = l.hitSegments(l.hits[id])
+		text.Refresh()
+	})
+	l.resultsList.OnSelected = func(id widget.ListItemID) {
+		l.resultsList.UnselectAll()
+		l.moveToHit(l.hits[id])
+	}
+
+	export := widget.NewButtonWithIcon("", theme.DocumentIcon(), func() {
+		if l.OnExportResults != nil && len(l.hits) > 0 {
+			l.OnExportResults(l.resultLines())
+		}
+	})
+	hide := widget.NewButtonWithIcon("", theme.CancelIcon(), l.toggleResults)
+	header := container.NewBorder(nil, nil, nil, container.NewHBox(export, hide), l.resultsLabel)
+	pane := container.NewBorder(header, nil, nil, nil, l.resultsList)
+	l.resultsSplit = container.NewHSplit(l, pane)
+	l.resultsSplit.Offset = 0.65
+}
+
+// toggleResults shows, or hides, the results pane
+func (l *TextList) toggleResults() {
+	if l.resultsShown() {
+		l.body.Objects[0] = l
+	} else {
+		l.body.Objects[0] = l.resultsSplit
+		l.refreshResults()
+	}
+	l.body.Refresh()
+}
+
+func (l *TextList) resultsShown() bool {
+	return l.body != nil && l.body.Objects[0] == l.resultsSplit
+}
+
+// resultsChanged finds the matches again, soon after the lines change
+func (l *TextList) resultsChanged() {
+	if !l.resultsShown() || l.resultsPending {
+		return
+	}
+	l.resultsPending = true
+	time.AfterFunc(200*time.Millisecond, func() {
+		fyne.Do(func() {
+			l.resultsPending = false
+			l.refreshResults()
+		})
+	})
+}
+
+// refreshResults lists the matches of the last search, in line order
+func (l *TextList) refreshResults() {
+	if !l.resultsShown() {
+		return
+	}
+	l.hits = nil
+	if l.searchText != "" {
+		for _, r := range l.findListMatch(0, l.searchText) {
+			l.hits = append(l.hits, buffer.Match{Line: r.rowId, Col1: r.col1, Col2: r.col2})
+		}
+	}
+	l.resultsLabel.SetText(fmt.Sprintf("%d matches of <%s>", len(l.hits), l.searchText))
+	l.resultsList.Refresh()
+}
+
+// hitSegments are the line number and text of a match, with the match highlighted
+func (l *TextList) hitSegments(m buffer.Match) []widget.RichTextSegment {
+	line := []rune(l.buffer.Line(m.Line))
+	col1 := min(m.Col1, len(line))
+	col2 := min(m.Col2+1, len(line))
+	from := max(col1-resultContext, 0)
+	before := fmt.Sprintf("%d: ", m.Line+1)
+	if from > 0 {
+		before += "…"
+	}
+	style := widget.RichTextStyle{Inline: true, TextStyle: fyne.TextStyle{Monospace: true}}
+	hit := style
+	hit.ColorName = theme.ColorNamePrimary
+	hit.TextStyle.Bold = true
+	return []widget.RichTextSegment{
+		&widget.TextSegment{Text: before + string(line[from:col1]), Style: style},
+		&widget.TextSegment{Text: string(line[col1:col2]), Style: hit},
+		&widget.TextSegment{Text: string(line[col2:]), Style: style},
+	}
+}
+
+// moveToHit moves to the row of a match, and makes it the current result when it is one
+func (l *TextList) moveToHit(m buffer.Match) {
+	for i, r := range l.results {
+		if r.rowId == m.Line && r.col1 == m.Col1 {
+			l.currentResult = i
+			l.resultCount.SetText(countForm(i+1, len(l.results)))
+			break
+		}
+	}
+	l.MoveToRow(m.Line)
+}
+
+// resultLines are the matches as lines "name:line: text", as grep shows them
+func (l *TextList) resultLines() (lines []string) {
+	for _, m := range l.hits {
+		text := fmt.Sprintf("%d: %s", m.Line+1, l.buffer.Line(m.Line))
+		if l.name.Text != "" {
+			text = l.name.Text + ":" + text
+		}
+		lines = append(lines, text)
+	}
+	return lines
+}
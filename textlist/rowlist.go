@@ -8,9 +8,11 @@ import (
 	"fyne.io/fyne/v2/layout"
 	"fyne.io/fyne/v2/theme"
 	"fyne.io/fyne/v2/widget"
+	"image/color"
 	"math"
 	"strings"
-	"unicode"
+	"time"
+	"unicode/utf8"
 )
 
 /*
@@ -28,29 +30,19 @@ import (
   Description: rowlist implements widget.List functions for managing a TextList
 */
 
-type listCell struct {
-	r      rune
-	style  *fyne.TextStyle
-	marked bool
-}
-
-type listRow struct {
-	cells  []listCell
-	style  *fyne.TextStyle
-	marked bool
-}
-
 func (l *TextList) setContent(content string) {
-	s := strings.Split(content, "\n")
-	for i := 0; i < len(s); i++ {
-		l.addString(s[i])
-	}
+	l.buffer.Append(strings.Split(content, "\n")...)
+	l.changed()
 }
 
 func (l *TextList) addString(str string) {
-	row := l.createRow(str)
-	l.rows = append(l.rows, row)
-	lb10 := int(math.Log10(float64(len(l.rows)))) + 1
+	l.buffer.Append(str)
+	l.changed()
+}
+
+// changed updates the line number formats for the Buffer size, and refreshes
+func (l *TextList) changed() {
+	lb10 := int(math.Log10(float64(max(l.buffer.Len(), 1)))) + 1
 	l.lineFormat = fmt.Sprintf(" %%%dd  ", lb10)
 	l.searchLineFormat = fmt.Sprintf(" %%%dd%s ", lb10, "\u2192")
 	l.Refresh()
@@ -68,22 +60,17 @@ func (l *TextList) updateItem(id widget.ListItemID, item fyne.CanvasObject) {
 	item.(*fyne.Container).Objects = append(item.(*fyne.Container).Objects,
 		l.lineNo(id))
 
-	runes := l.getRowRunes(id, false)
+	runes := l.getRowRunes(id)
+	if len(runes) > l.Theme.longLine {
+		l.updateLongItem(id, item, runes)
+		return
+	}
+	lineMarked := l.buffer.LineMarked(id)
 	for i := 0; i < len(runes); i++ {
 		r := runes[i]
-		cell := l.rows[id].cells[i]
 
 		var text *canvas.Text
-		var style = l.rows[id].cells[i].style
-
-		if style == nil {
-			style = l.rows[id].style
-		}
-		if style == nil {
-			style = l.style
-		}
-
-		if cell.marked || l.rows[id].marked {
+		if lineMarked || l.buffer.IsMarked(id, i) {
 			text = canvas.NewText(string(r), l.Theme.Color("markedColor", 0))
 		} else {
 			text = canvas.NewText(string(r), l.Theme.Color("normalColor", 0))
@@ -91,7 +78,7 @@ func (l *TextList) updateItem(id widget.ListItemID, item fyne.CanvasObject) {
 
 		text.Alignment = fyne.TextAlignCenter
 		text.TextSize = l.Theme.textSize
-		text.TextStyle = *style
+		text.TextStyle = *l.style
 
 		item.(*fyne.Container).Objects = append(item.(*fyne.Container).Objects, text)
 	}
@@ -99,6 +86,50 @@ func (l *TextList) updateItem(id widget.ListItemID, item fyne.CanvasObject) {
 
 }
 
+// updateLongItem wraps a long line every longLine runes, showing at most longLineRows rows.
+// A line longer than that is not edited in the Entry (see moveToRow).
+// Each row is drawn as a few runs of text (marked or not), rather than a Text per rune.
+func (l *TextList) updateLongItem(id widget.ListItemID, item fyne.CanvasObject, runes []rune) {
+	lineMarked := l.buffer.LineMarked(id)
+	marked := func(col int) bool {
+		return lineMarked || l.buffer.IsMarked(id, col)
+	}
+	newText := func(str string, c color.Color) *canvas.Text {
+		text := canvas.NewText(str, c)
+		text.TextSize = l.Theme.textSize
+		text.TextStyle = *l.style
+		return text
+	}
+
+	rows := container.NewVBox()
+	start := 0
+	for ; start < len(runes) && len(rows.Objects) < l.Theme.longLineRows; start += l.Theme.longLine {
+		end := min(start+l.Theme.longLine, len(runes))
+		row := container.New(layout.NewCustomPaddedHBoxLayout(0))
+		for run := start; run < end; {
+			m := marked(run)
+			next := run + 1
+			for next < end && marked(next) == m {
+				next++
+			}
+			c := l.Theme.Color("normalColor", 0)
+			if m {
+				c = l.Theme.Color("markedColor", 0)
+			}
+			row.Objects = append(row.Objects, newText(string(runes[run:next]), c))
+			run = next
+		}
+		rows.Objects = append(rows.Objects, row)
+	}
+	if start < len(runes) {
+		more := fmt.Sprintf("\u2026 %d more characters (not shown, nor editable but by the EDLIN commands)", len(runes)-start)
+		rows.Objects = append(rows.Objects, newText(more, Name2RGBA(theme.ColorNameHyperlink)))
+	}
+
+	item.(*fyne.Container).Objects = append(item.(*fyne.Container).Objects, rows)
+	l.SetItemHeight(id, l.Theme.textSize*float32(len(rows.Objects)))
+}
+
 func (l *TextList) onSelected(rowId widget.ListItemID) {
 	l.moveToRow(rowId)
 	l.focus(l)
@@ -109,13 +140,23 @@ func (l *TextList) moveToRow(rowId int) {
 	l.ScrollTo(rowId)
 	l.rowId = rowId
 	l.editText = l.getRowString(rowId)
+	if l.tooLongToEdit(rowId) {
+		l.editText = ""
+		l.edit.SetPlaceHolder(fmt.Sprintf("<line %d is too long to edit here: use the R command>", rowId+1))
+		l.edit.Disable()
+	} else {
+		l.edit.SetPlaceHolder("<empty>")
+		if !l.readOnly {
+			l.edit.Enable()
+		}
+	}
 	l.edit.SetText(l.editText)
 	l.Refresh()
 }
 
 func (l *TextList) pageDown(n int) {
 	rowId := l.rowId + n
-	rowId = min(rowId, len(l.rows)-1)
+	rowId = min(rowId, l.buffer.Len()-1)
 	off := float32(rowId) * l.Theme.textSize
 	l.ScrollToOffset(off)
 	l.rowId = rowId
@@ -129,18 +170,8 @@ func (l *TextList) pageUp(n int) {
 	l.ScrollToOffset(off)
 }
 
-func (l *TextList) clearMarkedRows(doCells bool) {
-	for rowId := 0; rowId < len(l.rows); rowId++ {
-		l.rows[rowId].marked = false
-		if doCells {
-			f := result{
-				rowId: rowId,
-				col1:  0,
-				col2:  len(l.rows[rowId].cells) - 1,
-			}
-			l.markCells(f, false)
-		}
-	}
+func (l *TextList) clearMarkedRows() {
+	l.buffer.ClearMarks()
 	l.startMark = -1
 	l.endMark = -1
 }
@@ -148,13 +179,13 @@ func (l *TextList) clearMarkedRows(doCells bool) {
 // markStartRow clears marks and marks the beginning of a new start row
 func (l *TextList) markStartRow(rowId int) {
 
-	l.clearMarkedRows(true)
-	if rowId >= len(l.rows)-1 {
+	l.clearMarkedRows()
+	if rowId >= l.buffer.Len() {
 		return
 	}
 
 	l.startMark = rowId
-	l.rows[rowId].marked = true
+	l.buffer.MarkLines(rowId, rowId)
 	l.endMark = -1
 	l.Refresh()
 }
@@ -163,7 +194,7 @@ func (l *TextList) markStartRow(rowId int) {
 func (l *TextList) markEndRow(rowId int) {
 
 	// may not be in mark mode, or invalid rowId
-	if l.startMark == -1 || rowId >= len(l.rows) {
+	if l.startMark == -1 || rowId >= l.buffer.Len() {
 		return
 	}
 	if l.endMark != -1 { // moving endMark
@@ -171,155 +202,105 @@ func (l *TextList) markEndRow(rowId int) {
 		l.markStartRow(sm)
 	}
 	l.endMark = rowId
-	inc := 1
-	if rowId > l.startMark {
-		inc = -1
-	}
-mark:
-	if rowId == l.startMark {
-		l.Refresh()
-		return
-	}
-	l.rows[rowId].marked = true
-	rowId += inc
-	goto mark
+	l.buffer.MarkLines(min(rowId, l.startMark), max(rowId, l.startMark))
+	l.Refresh()
 }
 
-func (l *TextList) getMarkedRows() (rows []string) {
+// markedRange returns the marked rows, or the current row if none are marked
+func (l *TextList) markedRange() (start, end int) {
 	if l.endMark == -1 {
-		rows = append(rows, l.getRowString(l.rowId))
-		return
-	}
-	start := l.startMark
-	end := l.endMark
-	if end < start {
-		start = l.endMark
-		end = l.startMark
-	}
-	for rowId := start; rowId <= end; rowId++ {
-		rows = append(rows, l.getRowString(rowId))
+		return l.rowId, l.rowId
 	}
-	return
+	return min(l.startMark, l.endMark), max(l.startMark, l.endMark)
+}
+
+func (l *TextList) getMarkedRows() (rows []string) {
+	start, end := l.markedRange()
+	return l.buffer.Lines(start, min(end, l.buffer.Len()-1))
 }
 
 func (l *TextList) deleteMarkedRows() (rows []string) {
-	if l.endMark == -1 {
-		l.deleteRow(l.rowId)
-	} else {
-		start := l.startMark
-		end := l.endMark
-		if end < start {
-			start = l.endMark
-			end = l.startMark
-		}
-		for rowId := start; rowId <= end; rowId++ {
-			rows = append(rows, l.getRowString(rowId))
-		}
-		n := end - start + 1
-		for i := 0; i < n; i++ {
-			l.deleteRow(start)
-		}
-	}
-	l.startMark = -1
-	l.endMark = -1
+	start, end := l.markedRange()
+	rows = l.buffer.Delete(start, end)
+	l.clearMarkedRows()
+	l.changed()
+	l.moveToRow(start)
 	return
 }
 
 func (l *TextList) insertRows(content string) {
-	rowId := l.rowId
-	s := strings.Split(content, "\n")
-	replace := make([]listRow, len(s))
-	for i := 0; i < len(s); i++ {
-		replace[i] = l.createRow(s[i])
-	}
-	rows := append(l.rows[:rowId], append(replace, l.rows[rowId:]...)...)
-	l.rows = rows
-	l.Refresh()
+	l.buffer.InsertString(l.rowId, content)
+	l.changed()
 }
 
 func (l *TextList) deleteRow(rowId int) {
 	switch {
-	case rowId >= len(l.rows): // last row
+	case rowId >= l.buffer.Len(): // last row
 	default:
-		rows := append(l.rows[:rowId], l.rows[rowId+1:]...)
-		l.rows = rows
+		l.buffer.Delete(rowId, rowId)
+		l.changed()
 		l.moveToRow(rowId)
 		l.UnselectAll()
 	}
 }
 
 func (l *TextList) replaceRow(rowId int, content string) {
-	lastRow := len(l.rows) - 1
 	s := strings.Split(content, "\n")
-	replace := make([]listRow, len(s))
-	rows := make([]listRow, 0)
-	for i := 0; i < len(s); i++ {
-		replace[i] = l.createRow(s[i])
-	}
-	if rowId >= lastRow {
-		rows = append(l.rows[:rowId], replace...)
+	if rowId >= l.buffer.Len() {
+		l.buffer.Insert(l.buffer.Len(), s...)
 	} else {
-		rows = append(l.rows[:rowId], append(replace, l.rows[rowId+1:]...)...)
-	}
-	l.rows = rows
-	if rowId == lastRow {
-		l.AddString("")
+		l.buffer.ReplaceRange(rowId, rowId, s...)
 	}
+	l.changed()
 }
 
 func (l *TextList) replaceCells(rowId, col1, col2 int, runes []rune, marked bool) {
-	row := l.rows[rowId]
-	replace := make([]listCell, len(runes))
-	for i := 0; i < len(runes); i++ {
-		replace[i] = listCell{
-			r:      runes[i],
-			style:  row.style,
-			marked: marked,
-		}
+	l.buffer.Splice(rowId, col1, col2, string(runes))
+	if marked {
+		l.buffer.Mark(rowId, col1, col1+len(runes)-1)
 	}
-	cells := append(row.cells[:col1], append(replace, row.cells[col2+1:]...)...)
-	l.rows[rowId].cells = cells
 }
 
-func (l *TextList) markCells(f result, mark bool) {
-	for col := f.col1; col <= f.col2; col++ {
-		l.rows[f.rowId].cells[col].marked = mark
-	}
+func (l *TextList) markCells(f result) {
+	l.buffer.Mark(f.rowId, f.col1, f.col2)
 }
 
-func (l *TextList) createRow(str string) listRow {
-	runes := []rune(str)
-	row := listRow{
-		cells: make([]listCell, len(runes)),
-		style: nil,
-	}
-	for i, r := range runes {
-		row.cells[i] = listCell{
-			r:     r,
-			style: nil,
-		}
+// undo reverts the last change to the Buffer
+func (l *TextList) undo() {
+	rowId, ok := l.buffer.Undo()
+	if !ok {
+		l.toast("Nothing to Undo", infoColor, 500*time.Millisecond)
+		return
 	}
-	return row
+	l.clearMarkedRows()
+	l.changed()
+	l.moveToRow(min(rowId, l.buffer.Len()))
 }
 
-func (l *TextList) getRowRunes(rowId int, ignoreCase bool) []rune {
-	if rowId >= len(l.rows) {
-		return nil
-	}
-	runes := make([]rune, len(l.rows[rowId].cells))
-	for i := 0; i < len(l.rows[rowId].cells); i++ {
-		r := l.rows[rowId].cells[i].r
-		if ignoreCase {
-			r = unicode.ToLower(r)
-		}
-		runes[i] = r
+// redo repeats the last undone change to the Buffer
+func (l *TextList) redo() {
+	rowId, ok := l.buffer.Redo()
+	if !ok {
+		l.toast("Nothing to Redo", infoColor, 500*time.Millisecond)
+		return
 	}
-	return runes
+	l.clearMarkedRows()
+	l.changed()
+	l.moveToRow(min(rowId, l.buffer.Len()))
+}
+
+func (l *TextList) getRowRunes(rowId int) []rune {
+	return []rune(l.buffer.Line(rowId))
 }
 
 func (l *TextList) getRowString(rowId int) string {
-	runes := l.getRowRunes(rowId, false)
-	return string(runes)
+	return l.buffer.Line(rowId)
+}
+
+// tooLongToEdit tells if a line is longer than is shown (longLine * longLineRows runes).
+// It is not loaded in the Entry, so it is changed only by the EDLIN commands.
+func (l *TextList) tooLongToEdit(rowId int) bool {
+	return utf8.RuneCountInString(l.getRowString(rowId)) > l.Theme.longLine*l.Theme.longLineRows
 }
 
 func (l *TextList) lineNo(rowId int) *canvas.Text {
@@ -1,16 +1,16 @@
 package textlist
 
 import (
+	"edlin/textlist/buffer"
 	"fmt"
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/canvas"
 	"fyne.io/fyne/v2/container"
-	"fyne.io/fyne/v2/data/binding"
 	"fyne.io/fyne/v2/layout"
 	"fyne.io/fyne/v2/theme"
 	"fyne.io/fyne/v2/widget"
+	"regexp"
 	"time"
-	"unicode"
 )
 
 /*
@@ -32,11 +32,21 @@ import (
 func (l *TextList) showSearch() {
 	l.mode = modeSearch
 	l.editBox.Hide()
+	l.commandBox.Hide()
 	l.searchBox.Show()
 	l.search.Enable()
-	l.search.SetText("")
+	text := l.searchText // the last search is offered again
 	l.searchText = ""
+	l.search.SetText(text)
 	l.replace.SetText("")
+	l.rangeStart, l.rangeEnd = -1, -1
+	if l.startMark >= 0 {
+		end := l.endMark
+		if end < 0 {
+			end = l.startMark
+		}
+		l.rangeStart, l.rangeEnd = min(l.startMark, end), max(l.startMark, end)
+	}
 	l.disableReplace()
 	l.UnselectAll()
 	l.focus(l.search)
@@ -52,28 +62,66 @@ func (l *TextList) searchView(buttonBar *fyne.Container) {
 
 	sr := container.NewVBox(l.search, l.replace)
 
-	ignoreCase := widget.NewCheckWithData("IgnoreCase", binding.BindBool(&l.ignoreCase))
+	changed := false
+
+	// a change of option searches again
+	ignoreCase := widget.NewCheck("IgnoreCase", func(b bool) {
+		l.options.IgnoreCase = b
+		changed = true
+	})
+	wholeWord := widget.NewCheck("Whole Word", func(b bool) {
+		l.options.WholeWord = b
+		changed = true
+	})
+	regex := widget.NewCheck("Regex", func(b bool) {
+		l.regex = b
+		changed = true
+	})
+	normalize := widget.NewCheck("Normalize", func(b bool) {
+		l.options.Normalize = b
+		changed = true
+	})
+	preserveCase := widget.NewCheck("Preserve Case", func(b bool) {
+		l.preserveCase = b
+	})
 	l.resultCount = widget.NewLabel(countForm(0, 0))
 	l.down = widget.NewButtonWithIcon("", theme.Icon(theme.IconNameMoveDown), nil)
 	l.up = widget.NewButtonWithIcon("", theme.Icon(theme.IconNameMoveUp), nil)
-	search := container.NewHBox(l.resultCount, l.up, l.down)
+	results := widget.NewButtonWithIcon("", theme.ListIcon(), l.toggleResults)
+	search := container.NewHBox(l.resultCount, l.up, l.down, results)
 	cancel := widget.NewButtonWithIcon("", theme.CancelIcon(), nil)
 
-	searching := container.NewVBox(ignoreCase, search, layout.NewSpacer(), cancel)
+	l.replaceAll = widget.NewButton("All", func() {
+		l.confirmReplaceAll(false)
+	})
+	l.replaceRange = widget.NewButton("In Range", func() {
+		l.confirmReplaceAll(true)
+	})
+	replaceAll := container.NewHBox(widget.NewLabel("Replace"), l.replaceAll, l.replaceRange)
+
+	options := container.NewGridWithColumns(2, ignoreCase, wholeWord, regex, normalize)
+	searching := container.NewVBox(options, search, replaceAll, preserveCase,
+		layout.NewSpacer(), cancel)
 
 	l.replaceAction = widget.NewButtonWithIcon("", theme.ConfirmIcon(),
 		func() {
-			l.replaceCells(l.results[l.currentResult].rowId,
-				l.results[l.currentResult].col1,
-				l.results[l.currentResult].col2,
-				[]rune(l.replace.Text), true)
+			if l.currentResult >= len(l.results) {
+				return
+			}
+			r := l.results[l.currentResult]
+			if r.rowId < 0 { // replaced
+				l.nextResult(1)
+				return
+			}
+			runes := []rune(l.replacement(r))
+			l.replaceCells(r.rowId, r.col1, r.col2, runes, true)
 			l.results[l.currentResult].rowId = -1
+			l.shiftResults(r, len(runes)-(r.col2-r.col1+1))
+			l.nextResult(1) // or disable replace, when none are left
 			l.Refresh()
 		})
 	l.replace.ActionItem = l.replaceAction
 
-	changed := false
-
 	queryAction := widget.NewButtonWithIcon("", theme.SearchIcon(),
 		func() {
 			if changed { // no different, ignore
@@ -121,7 +169,7 @@ func (l *TextList) searchView(buttonBar *fyne.Container) {
 
 func moveToOffset(l *TextList, rowId int, next int) {
 	rowId += 5 * next
-	rowId = min(rowId, len(l.rows)-1)
+	rowId = min(rowId, l.buffer.Len()-1)
 	off := float32(rowId) * l.Theme.textSize
 	l.ScrollToOffset(off)
 	l.ScrollTo(l.rowId + next)
@@ -133,13 +181,22 @@ func (l *TextList) disableReplace() {
 	l.up.Disable()
 	l.replace.Disable()
 	l.replaceAction.Disable()
+	l.replaceAll.Disable()
+	l.replaceRange.Disable()
 	l.resultCount.SetText(countForm(0, 0))
 }
 
+// clearResults ends the search results, as the rows they are of changed
+func (l *TextList) clearResults() {
+	l.results = nil
+	l.currentResult = 0
+	l.disableReplace()
+}
+
 func (l *TextList) nextResult(next int) (found bool) {
 
 	for _, r := range l.results {
-		if r.rowId > 0 {
+		if r.rowId > -1 {
 			found = true
 			break
 		}
@@ -173,19 +230,33 @@ loop:
 }
 
 func (l *TextList) query(str string) bool {
-	find := str
-	rowId := l.rowId
-	ic := l.ignoreCase
-	l.results = l.findListMatch(rowId, find, ic)
+	l.searchText = str
+	l.searchRegexp = nil
+	if l.regex {
+		pattern := str
+		if l.options.IgnoreCase {
+			pattern = "(?i)" + pattern
+		}
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			l.results = nil
+			l.disableReplace()
+			l.toast(err.Error(), failColor, 2*time.Second)
+			return false
+		}
+		l.searchRegexp = re
+	}
+	l.results = l.findListMatch(l.rowId, str)
+	l.refreshResults()
 	if len(l.results) < 1 {
 		l.resultCount.SetText(countForm(0, 0))
 		m := fmt.Sprintf("<%s> Not Found", str)
 		l.toast(m, infoColor, 500*time.Millisecond)
 		return false
 	}
-	l.clearMarkedRows(true)
+	l.clearMarkedRows()
 	for i := 0; i < len(l.results); i++ {
-		l.markCells(l.results[i], true)
+		l.markCells(l.results[i])
 	}
 	if len(l.results) > 0 {
 		l.down.Enable()
@@ -193,74 +264,66 @@ func (l *TextList) query(str string) bool {
 	}
 	l.currentResult = 0
 	l.resultCount.SetText(countForm(1, len(l.results)))
-	l.replace.Enable()
-	l.replaceAction.Enable()
+	if !l.readOnly {
+		l.replace.Enable()
+		l.replaceAction.Enable()
+		l.replaceAll.Enable()
+		if l.rangeStart >= 0 {
+			l.replaceRange.Enable()
+		}
+	}
 	l.Refresh()
 	return true
 }
 
-// findListMatch finds the rows that have 1 match
-func (l *TextList) findListMatch(startRow int, find string, ignoreCase bool) (fs []result) {
-
-	match := []rune(find)
-	if ignoreCase {
-		for g := 0; g < len(find); g++ {
-			match[g] = unicode.ToLower(match[g])
-		}
+// findListMatch finds each match of each row, of the regexp of the query when it has one
+func (l *TextList) findListMatch(startRow int, find string) (fs []result) {
+	var matches []buffer.Match
+	var subs []buffer.Submatch
+	if l.searchRegexp != nil {
+		matches = l.buffer.SearchRegexp(startRow, l.searchRegexp, l.options)
+		subs = l.buffer.Submatches(l.searchRegexp, matches)
+	} else {
+		matches = l.buffer.Search(startRow, find, l.options)
 	}
-	nRows := len(l.rows)
-	for i := 0; i < nRows; i++ {
-		runes := l.getRowRunes(startRow, ignoreCase)
-		fs = append(fs, findRowMatch(startRow, runes, match)...)
-		startRow++
-		if startRow >= len(l.rows)-1 {
-			startRow = 0
+	for i, m := range matches {
+		r := result{
+			rowId: m.Line,
+			col1:  m.Col1,
+			col2:  m.Col2,
+		}
+		if subs != nil {
+			r.match = subs[i]
 		}
+		fs = append(fs, r)
 	}
 	return
 }
 
-// findListMatch finds the cells that match
-func findRowMatch(rowId int, runes []rune, match []rune) (fs []result) {
-	f := findCellMatch(runes, match)
-	if f == nil {
-		return
+// shiftResults moves the later results of the row of a replaced result by delta columns
+func (l *TextList) shiftResults(replaced result, delta int) {
+	for i, r := range l.results {
+		if r.rowId == replaced.rowId && r.col1 > replaced.col2 {
+			l.results[i].col1 += delta
+			l.results[i].col2 += delta
+		}
 	}
-	f.rowId = rowId
-	fs = append(fs, *f)
-	return
-}
-
-func countForm(n, m int) string {
-	return fmt.Sprintf("%3d/%-3d", n, m)
 }
 
-func findCellMatch(runes []rune, match []rune) *result {
-	rx := 0
-	mx := 0
-	inMatch := false
-	var f result
-cellLoop:
-	if rx >= len(runes) {
-		return nil
+// replacement is the replace text for a result. A regexp query expands $1 and ${name} in it
+// (with the groups of the match when found), and Preserve Case gives it the case of the match.
+func (l *TextList) replacement(r result) string {
+	text := l.replace.Text
+	if l.searchRegexp != nil {
+		text = r.match.Expand(l.searchRegexp, text)
 	}
-	r := runes[rx]
-	m := match[mx]
-	if r == m {
-		if !inMatch {
-			f.col1 = rx
-			inMatch = true
-		}
-		rx++
-		mx++
-		if rx-f.col1 >= len(match) {
-			f.col2 = rx - 1
-			return &f
-		}
-		goto cellLoop
+	if l.preserveCase {
+		line := []rune(l.buffer.Line(r.rowId))
+		text = buffer.PreserveCase(string(line[min(r.col1, len(line)):min(r.col2+1, len(line))]), text)
 	}
-	rx++
-	mx = 0
-	inMatch = false
-	goto cellLoop
+	return text
+}
+
+func countForm(n, m int) string {
+	return fmt.Sprintf("%3d/%-3d", n, m)
 }
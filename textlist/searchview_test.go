@@ -0,0 +1,44 @@
+package textlist
+
+import (
+	"slices"
+	"testing"
+)
+
+/*
+
+  File:    searchview_test.go
+  Author:  Bob Shofner
+
+  MIT License - https://opensource.org/license/mit/
+
+  This permission notice shall be included in all copies
+    or substantial portions of the Software.
+
+*/
+/*
+  Description: tests of the search results, as matches are replaced.
+*/
+
+func TestShiftResults(t *testing.T) {
+	l := &TextList{results: []result{
+		{rowId: 0, col1: 0, col2: 1},
+		{rowId: 0, col1: 3, col2: 4},
+		{rowId: 0, col1: 6, col2: 7},
+		{rowId: 1, col1: 3, col2: 4},
+	}}
+	replaced := l.results[1]
+	l.shiftResults(replaced, 2) // "go" replaced by "gold"
+	l.shiftResults(l.results[0], -1)
+	want := []result{
+		{rowId: 0, col1: 0, col2: 1},
+		{rowId: 0, col1: 2, col2: 3},
+		{rowId: 0, col1: 7, col2: 8},
+		{rowId: 1, col1: 3, col2: 4},
+	}
+	if !slices.EqualFunc(l.results, want, func(a, b result) bool {
+		return a.rowId == b.rowId && a.col1 == b.col1 && a.col2 == b.col2
+	}) {
+		t.Errorf("results %v, want %v", l.results, want)
+	}
+}
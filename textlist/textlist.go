@@ -1,12 +1,15 @@
 package textlist
 
 import (
+	"edlin/textlist/buffer"
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/canvas"
 	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/theme"
 	"fyne.io/fyne/v2/widget"
 	"image/color"
 	"iter"
+	"regexp"
 	"strings"
 	"time"
 	//"time"
@@ -34,9 +37,18 @@ type TextList struct {
 	Theme  MyTheme
 	window fyne.Window
 
-	rows               []listRow
+	OnModified    func(modified bool)  // called when the content is first changed, or saved
+	OnNameDragged func(dx, dy float32) // the name (above the list) is dragged
+	OnNameDragEnd func()
+	// OnNameTappedSecondary is a right click on the name, at a canvas position
+	OnNameTappedSecondary func(pos fyne.Position)
+	// OnExportResults is given the lines of the search results pane, for a new tab
+	OnExportResults func(lines []string)
+
+	buffer             *buffer.Buffer
 	rowId              int
 	startMark, endMark int
+	readOnly           bool
 
 	controlBox *fyne.Container
 
@@ -57,7 +69,32 @@ type TextList struct {
 	resultCount   *widget.Label
 	down          *widget.Button
 	up            *widget.Button
-	ignoreCase    bool
+	options       buffer.SearchOptions
+	regex         bool
+	preserveCase  bool
+	searchRegexp  *regexp.Regexp // of the last query, when regex
+	replaceAll    *widget.Button
+	replaceRange  *widget.Button
+	rangeStart    int // the marked rows when searching began, or -1
+	rangeEnd      int
+
+	body           *fyne.Container // the list, or the list and the results pane
+	resultsSplit   *container.Split
+	resultsList    *widget.List
+	resultsLabel   *widget.Label
+	hits           []buffer.Match // shown in the results pane
+	resultsPending bool
+
+	command     *widget.Entry
+	commandBox  *fyne.Container
+	prompt      *widget.Label
+	inserting   bool
+	insertAt    int
+	lastSearch  string
+	lastReplace string
+
+	status *widget.Label
+	name   *nameLabel
 
 	style            *fyne.TextStyle
 	spaces           string
@@ -70,7 +107,7 @@ type result struct {
 	rowId int
 	col1  int
 	col2  int
-	text  string
+	match buffer.Submatch // of a regexp query, taken when found, as replacing changes the line
 }
 
 // NewTextList creates a container with a TextList "widget"
@@ -79,6 +116,7 @@ func NewTextList(window fyne.Window, name string, buttonBar *fyne.Container,
 
 	l := &TextList{
 		window:    window,
+		buffer:    buffer.NewBuffer(),
 		Theme:     theme,
 		style:     &theme.style,
 		spaces:    strings.Repeat(" ", theme.tabSize),
@@ -88,10 +126,12 @@ func NewTextList(window fyne.Window, name string, buttonBar *fyne.Container,
 	}
 	l.ExtendBaseWidget(l)
 	l.HideSeparators = true
+	l.buffer.SetUndoDepth(theme.undoDepth)
+	l.watchBuffer()
 
 	// delegate List functions
 	l.Length = func() int {
-		return len(l.rows) + 1
+		return l.buffer.Len() + 1
 	}
 	l.CreateItem = func() fyne.CanvasObject {
 		return l.createItem()
@@ -104,15 +144,16 @@ func NewTextList(window fyne.Window, name string, buttonBar *fyne.Container,
 	}
 
 	l.views(buttonBar)
-
-	if len(name) > 0 {
-		label := widget.NewLabel(name)
-		label.Alignment = fyne.TextAlignTrailing
-		sep := canvas.NewLine(l.Theme.Color("normalColor", 0))
-		return l, container.NewBorder(container.NewVBox(label, sep), l.controlBox, nil, nil, l)
-	}
-
-	return l, container.NewBorder(nil, l.controlBox, nil, nil, l)
+	l.resultsView()
+
+	// the file format (line endings, ...) is shown left of the name
+	l.status = widget.NewLabel("")
+	l.showStatus()
+	l.name = newNameLabel(l, name)
+	sep := canvas.NewLine(l.Theme.Color("normalColor", 0))
+	header := container.NewVBox(container.NewBorder(nil, nil, l.status, nil, l.name), sep)
+	l.body = container.NewStack(l)
+	return l, container.NewBorder(header, l.controlBox, nil, nil, l.body)
 }
 
 // SetContent adds all the strings (separated by \n)
@@ -125,6 +166,138 @@ func (l *TextList) AddString(str string) {
 	l.addString(str)
 }
 
+// Buffer returns the Buffer being edited
+func (l *TextList) Buffer() *buffer.Buffer {
+	return l.buffer
+}
+
+// SetBuffer replaces the Buffer being edited
+func (l *TextList) SetBuffer(b *buffer.Buffer) {
+	l.buffer = b
+	l.buffer.SetUndoDepth(l.Theme.undoDepth)
+	l.watchBuffer()
+	l.rowId = 0
+	l.clearResults()
+	l.clearMarkedRows()
+	l.showStatus()
+	l.changed()
+}
+
+// SetName changes the name (file path) shown above the list
+func (l *TextList) SetName(name string) {
+	l.name.SetText(name)
+}
+
+// Modified reports whether the content changed since it was read (or last saved)
+func (l *TextList) Modified() bool {
+	return l.buffer.Modified()
+}
+
+// watchBuffer passes changes of the Buffer modified flag to OnModified
+func (l *TextList) watchBuffer() {
+	l.buffer.OnModified = func(modified bool) {
+		if l.OnModified != nil {
+			l.OnModified(modified)
+		}
+	}
+	l.buffer.OnChange = l.resultsChanged
+	l.resultsChanged()
+}
+
+// Format returns how the content is written to a file
+func (l *TextList) Format() buffer.Format {
+	return l.buffer.Format()
+}
+
+// SetFormat changes how the content is written to a file
+func (l *TextList) SetFormat(f buffer.Format) {
+	l.buffer.SetFormat(f)
+	l.showStatus()
+}
+
+// SetWindow moves the TextList (its dialogs, toasts and clipboard) to another window
+func (l *TextList) SetWindow(window fyne.Window) {
+	l.window = window
+}
+
+// ReadOnly reports whether the content may not be changed
+func (l *TextList) ReadOnly() bool {
+	return l.readOnly
+}
+
+// SetReadOnly prevents (or allows) changes to the content
+func (l *TextList) SetReadOnly(readOnly bool) {
+	l.readOnly = readOnly
+	if readOnly {
+		l.edit.Disable()
+		l.disableReplace()
+	} else if !l.tooLongToEdit(l.rowId) {
+		l.edit.Enable()
+	}
+	l.showStatus()
+}
+
+// editable reports whether the content may be changed, telling the user when not
+func (l *TextList) editable() bool {
+	if l.readOnly {
+		l.toast("Read Only", failColor, 500*time.Millisecond)
+	}
+	return !l.readOnly
+}
+
+func (l *TextList) showStatus() {
+	status := l.buffer.Format().String()
+	if l.readOnly {
+		status += " read only"
+	}
+	l.status.SetText(status)
+}
+
+// Row returns the current row
+func (l *TextList) Row() int {
+	return l.rowId
+}
+
+// MoveToRow makes rowId the current row, and scrolls to it
+func (l *TextList) MoveToRow(rowId int) {
+	l.moveToRow(min(max(rowId, 0), max(l.buffer.Len()-1, 0)))
+}
+
+// SearchText returns the text last searched for
+func (l *TextList) SearchText() string {
+	return l.searchText
+}
+
+// SetSearchText sets the text offered when search is next shown
+func (l *TextList) SetSearchText(text string) {
+	l.searchText = text
+}
+
+// Insert inserts lines before rowId, as one undo step
+func (l *TextList) Insert(rowId int, lines ...string) {
+	if !l.editable() {
+		return
+	}
+	rowId = min(max(rowId, 0), l.buffer.Len())
+	l.buffer.Insert(rowId, lines...)
+	l.clearResults()
+	l.changed()
+	l.moveToRow(rowId)
+}
+
+// RowAt returns the row shown at pos (a canvas position), if pos is on the list.
+// As with paging, every row is taken to be one line high.
+func (l *TextList) RowAt(pos fyne.Position) (int, bool) {
+	abs := fyne.CurrentApp().Driver().AbsolutePositionForObject(l)
+	x, y := pos.X-abs.X, pos.Y-abs.Y
+	if x < 0 || y < 0 || x > l.Size().Width || y > l.Size().Height {
+		return 0, false
+	}
+	height := l.Theme.textSize + l.Theme.Size(theme.SizeNamePadding)
+	row := int((y + l.GetScrollOffset()) / height)
+	return min(row, l.buffer.Len()), true
+}
+
 // Start starts the TextList in edit mode
 func (l *TextList) Start() {
 	l.showEdit()
@@ -132,23 +305,18 @@ func (l *TextList) Start() {
 
 // Count returns the current number of text rows
 func (l *TextList) Count() int {
-	return len(l.rows)
+	return l.buffer.Len()
 }
 
 // GetContent returns a []string for all the rows
 func (l *TextList) GetContent() []string {
-	content := make([]string, len(l.rows))
-	for ix := range l.rows {
-		content[ix] = l.getRowString(ix)
-	}
-	return content
+	return l.buffer.Lines(0, l.buffer.Len()-1)
 }
 
 // Iterator provides a GO 1.23 range operator
 func Iterator(l *TextList) iter.Seq[string] {
 	return func(yield func(string) bool) {
-		for i := 0; i < len(l.rows); i++ {
-			str := l.getRowString(i)
+		for _, str := range l.buffer.All() {
 			if !yield(str) { // return str, quit if scope has ended
 				return
 			}
@@ -171,8 +339,9 @@ func (l *TextList) focus(f fyne.Focusable) {
 }
 
 const (
-	modeEdit   = 0
-	modeSearch = 1
+	modeEdit    = 0
+	modeSearch  = 1
+	modeCommand = 2
 )
 
 func (l *TextList) TypedShortcut(s fyne.Shortcut) {
@@ -198,7 +367,7 @@ func (l *TextList) TypedShortcut(s fyne.Shortcut) {
 		l.UnselectAll()
 		l.ScrollToBottom() // doesn't go to the "end"
 		l.ScrollToBottom() // do it twice?
-		l.rowId = len(l.rows) - 1
+		l.rowId = l.buffer.Len() - 1
 	case "CustomDesktop:Control+Down", "CustomDesktop:Control+Next", "CustomDesktop:Alt+Down":
 		l.pageDown(10)
 	case "CustomDesktop:Control+Up", "CustomDesktop:Control+Prior", "CustomDesktop:Alt+Up":
@@ -209,6 +378,11 @@ func (l *TextList) TypedShortcut(s fyne.Shortcut) {
 			l.showSearch()
 		}
 
+	case "CustomDesktop:Control+L":
+		if l.mode == modeEdit {
+			l.showCommand()
+		}
+
 	case "CustomDesktop:Control+M":
 		if l.mode == modeEdit {
 			l.markStartRow(l.rowId)
@@ -219,7 +393,7 @@ func (l *TextList) TypedShortcut(s fyne.Shortcut) {
 		}
 
 	case "Cut", "CustomDesktop:Control+X": // Ctrl+X
-		if l.mode == modeEdit {
+		if l.mode == modeEdit && l.editable() {
 			str := collectRows(l.deleteMarkedRows())
 			cb := l.window.Clipboard()
 			cb.SetContent(str)
@@ -231,11 +405,24 @@ func (l *TextList) TypedShortcut(s fyne.Shortcut) {
 			cb.SetContent(str)
 		}
 	case "Paste", "CustomDesktop:Control+V": // Ctrl+V
-		if l.mode == modeEdit {
+		if l.mode == modeEdit && l.editable() {
 			cb := l.window.Clipboard()
 			str := cb.Content()
 			l.insertRows(str)
 		}
 
+	case "Undo", "CustomDesktop:Control+Z": // Ctrl+Z
+		if l.mode == modeEdit && l.editable() {
+			l.undo()
+		}
+	case "Redo", "CustomDesktop:Control+Y": // Ctrl+Y
+		if l.mode == modeEdit && l.editable() {
+			l.redo()
+		}
+
+	default: // let the window handle its own shortcuts (such as Save)
+		if c, ok := l.window.Canvas().(fyne.Shortcutable); ok {
+			c.TypedShortcut(s)
+		}
 	}
 }
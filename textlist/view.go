@@ -1,6 +1,7 @@
 package textlist
 
 import (
+	"edlin/textlist/buffer"
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/container"
 	"fyne.io/fyne/v2/theme"
@@ -20,7 +21,7 @@ import (
 */
 /*
   Description: setup view containers.
-	A Stack contains the views for EDIT, SEARCH.
+	A Stack contains the views for EDIT, SEARCH, COMMAND.
     Only 1 is visible based on user interactions.
 
 	TEXT and TAB sizes are maintained in fyne preferences.
@@ -30,9 +31,10 @@ func (l *TextList) views(buttonBar *fyne.Container) {
 
 	l.editView(buttonBar)
 	l.searchView(buttonBar)
+	l.commandView(buttonBar)
 
-	// edit and search containers. one is visible
-	l.controlBox = container.NewStack(l.searchBox, l.editBox)
+	// edit, search and command containers. one is visible
+	l.controlBox = container.NewStack(l.commandBox, l.searchBox, l.editBox)
 }
 
 // TextList specific fyne.Theme
@@ -44,6 +46,9 @@ type MyTheme struct {
 	tabSize       int
 	separatorSize float32
 	doubleClick   int
+	undoDepth     int
+	longLine      int
+	longLineRows  int
 	style         fyne.TextStyle
 	variant       fyne.ThemeVariant
 }
@@ -58,6 +63,9 @@ func NewTheme(settings fyne.Settings, prefs fyne.Preferences) *MyTheme {
 	t.tabSize = prefs.IntWithFallback("sizeTab", 4)
 	t.separatorSize = float32(prefs.FloatWithFallback("sizeSeparator", 0))
 	t.doubleClick = prefs.IntWithFallback("doubleClick", 500)
+	t.undoDepth = prefs.IntWithFallback("undoDepth", buffer.DefaultUndoDepth)
+	t.longLine = max(prefs.IntWithFallback("longLine", 1000), 10)
+	t.longLineRows = max(prefs.IntWithFallback("longLineRows", 100), 1)
 	t.style.Monospace = false
 	t.style.TabWidth = t.tabSize
 
@@ -67,6 +75,9 @@ func NewTheme(settings fyne.Settings, prefs fyne.Preferences) *MyTheme {
 	prefs.SetInt("sizeTab", t.tabSize)
 	prefs.SetFloat("sizeSeparator", float64(t.separatorSize))
 	prefs.SetInt("doubleClick", t.doubleClick)
+	prefs.SetInt("undoDepth", t.undoDepth)
+	prefs.SetInt("longLine", t.longLine)
+	prefs.SetInt("longLineRows", t.longLineRows)
 
 	settings.SetTheme(t)
 	return t
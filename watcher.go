@@ -0,0 +1,212 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+	"github.com/fsnotify/fsnotify"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+/*
+
+  File:    watcher.go
+  Author:  Bob Shofner
+
+  MIT License - https://opensource.org/license/mit/
+
+  This permission notice shall be included in all copies
+    or substantial portions of the Software.
+
+*/
+/*
+  Description: watch the files of the tabs for changes made by other programs.
+	The folder of each file is watched, as a save by rename replaces the file.
+	A tab without unsaved changes is reloaded. Otherwise, a banner offers
+	to Reload, Keep Mine or Compare. Saving a tab records its file's time
+	and size, so EDLIN's own saves are not reported.
+*/
+
+// banner tells of a change to the file of a tab, on disk
+type banner struct {
+	box    *fyne.Container
+	text   *widget.Label
+	reload *widget.Button
+}
+
+var fileWatcher *fsnotify.Watcher
+var watchedDirs = make(map[string]int)
+
+// startWatcher watches the folders of the tab files. Changes are checked once they settle.
+func startWatcher() {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		log.Println("watch:", err.Error())
+		return
+	}
+	fileWatcher = watcher
+	go func() {
+		timers := make(map[string]*time.Timer)
+		for {
+			select {
+			case event, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				name := filepath.Clean(event.Name)
+				if timer, ok := timers[name]; ok {
+					timer.Reset(200 * time.Millisecond)
+				} else {
+					timers[name] = time.AfterFunc(200*time.Millisecond, func() {
+						fyne.Do(func() {
+							checkFile(name)
+						})
+					})
+				}
+			case err, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+				log.Println("watch:", err.Error())
+			}
+		}
+	}()
+}
+
+// watchFile starts watching the folder of path
+func watchFile(path string) {
+	if fileWatcher == nil || path == "" {
+		return
+	}
+	dir := filepath.Dir(path)
+	if watchedDirs[dir] == 0 {
+		if err := fileWatcher.Add(dir); err != nil {
+			log.Println("watch:", err.Error())
+			return
+		}
+	}
+	watchedDirs[dir]++
+}
+
+// unwatchFile stops watching the folder of path, when no other tab is in it
+func unwatchFile(path string) {
+	dir := filepath.Dir(path)
+	if fileWatcher == nil || path == "" || watchedDirs[dir] == 0 {
+		return
+	}
+	watchedDirs[dir]--
+	if watchedDirs[dir] == 0 {
+		delete(watchedDirs, dir)
+		_ = fileWatcher.Remove(dir)
+	}
+}
+
+// statTab records the time and size of the file of a tab, as it is known to the tab
+func statTab(t *tab) {
+	t.diskTime, t.diskSize = time.Time{}, 0
+	if info, err := os.Stat(t.path); err == nil {
+		t.diskTime, t.diskSize = info.ModTime(), info.Size()
+	}
+}
+
+// checkFile checks each tab of the file name
+func checkFile(name string) {
+	for _, t := range allTabs() {
+		if filepath.Clean(t.path) == name {
+			checkTab(t)
+		}
+	}
+}
+
+// checkTab compares the file of a tab to when it was read (or saved)
+func checkTab(t *tab) {
+	info, err := os.Stat(t.path)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		if !t.diskTime.IsZero() {
+			t.diskTime, t.diskSize = time.Time{}, 0
+			showBanner(t, "was deleted on disk", false)
+		}
+	case err != nil:
+		log.Println("watch:", err.Error())
+	case info.ModTime().Equal(t.diskTime) && info.Size() == t.diskSize:
+		// unchanged
+	case !t.editor.Modified():
+		reloadTab(t)
+	default:
+		showBanner(t, "was changed on disk", true)
+	}
+}
+
+// newBanner creates the (hidden) banner of a tab item
+func newBanner(item *container.TabItem) *banner {
+	b := &banner{text: widget.NewLabel("")}
+	b.reload = widget.NewButton("Reload", func() {
+		if t, ok := tabMap[item]; ok {
+			reloadTab(t)
+		}
+	})
+	keep := widget.NewButton("Keep Mine", func() {
+		if t, ok := tabMap[item]; ok {
+			keepMine(t)
+		}
+	})
+	compare := widget.NewButton("Compare", func() {
+		if t, ok := tabMap[item]; ok {
+			compareTab(t.win.window, t)
+		}
+	})
+	b.box = container.NewBorder(nil, nil, nil, container.NewHBox(b.reload, keep, compare), b.text)
+	b.box.Hide()
+	return b
+}
+
+func showBanner(t *tab, text string, reload bool) {
+	t.banner.text.SetText(fmt.Sprintf("%s %s", t.title, text))
+	if reload {
+		t.banner.reload.Enable()
+	} else {
+		t.banner.reload.Disable()
+	}
+	t.banner.box.Show()
+}
+
+// reloadTab replaces the content of a tab with its file
+func reloadTab(t *tab) {
+	buffer, err := readFile(t.path)
+	if err != nil {
+		showBanner(t, err.Error(), true)
+		return
+	}
+	row := t.editor.Row()
+	removeJournal(t)
+	t.journaled = -1
+	t.editor.SetBuffer(buffer)
+	t.editor.MoveToRow(row)
+	t.banner.box.Hide()
+	statTab(t)
+	showTitles()
+}
+
+// keepMine ignores the change on disk. The tab is unsaved, as it differs from its file.
+func keepMine(t *tab) {
+	t.banner.box.Hide()
+	statTab(t)
+	t.editor.Buffer().SetModified(true)
+}
+
+// compareTab shows the changes from the file on disk to the tab
+func compareTab(w fyne.Window, t *tab) {
+	onDisk, _ := fileLines(t.path)
+	mine := t.editor.Buffer().Lines(0, t.editor.Count()-1)
+	d := dialog.NewCustom("Compare "+t.title+" (on disk to mine)", "Close", diffView(onDisk, mine), w)
+	d.Resize(fyne.NewSize(w.Canvas().Size().Width*0.8, w.Canvas().Size().Height*0.8))
+	d.Show()
+}
@@ -0,0 +1,175 @@
+package main
+
+import (
+	"edlin/textlist"
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/driver/desktop"
+	"slices"
+)
+
+/*
+
+  File:    window.go
+  Author:  Bob Shofner
+
+  MIT License - https://opensource.org/license/mit/
+
+  This permission notice shall be included in all copies
+    or substantial portions of the Software.
+
+*/
+/*
+  Description: windows of tabs.
+	Each window has its own tabs, menus and shortcuts, which act on
+	that window. A tab is moved to a new window with its menu, or by
+	dragging its name down. EDLIN ends when the last window is closed.
+*/
+
+type editorWindow struct {
+	window   fyne.Window
+	tabItems *container.DocTabs
+	tabs     []*tab // in the order shown
+	tabix    int    // of the selected tab
+}
+
+var windows []*editorWindow // in the order opened
+var focused *editorWindow   // last used, where files from another EDLIN open
+
+// newWindow creates a window without tabs. It is not shown.
+func newWindow(theme *textlist.MyTheme) *editorWindow {
+	w := fyne.CurrentApp().NewWindow(appTitle)
+	w.SetIcon(resourceTypewriterPng)
+	ew := &editorWindow{window: w, tabItems: container.NewDocTabs()}
+	ew.tabItems.SetTabLocation(container.TabLocationTop)
+	ew.tabItems.OnSelected = func(*container.TabItem) {
+		focused = ew
+		syncTabs()
+	}
+	// closing a tab, or the window, asks to save unsaved changes
+	ew.tabItems.CloseIntercept = func(item *container.TabItem) {
+		t, ok := tabMap[item]
+		if !ok || !t.editor.Modified() {
+			closeTab(item)
+			return
+		}
+		confirmUnsaved(w, []*tab{t}, func() {
+			closeTab(item)
+		})
+	}
+	w.SetCloseIntercept(func() {
+		confirmUnsaved(w, ew.unsavedTabs(), ew.close)
+	})
+
+	w.Resize(fyne.NewSize(float32(theme.Width), float32(theme.Height)))
+
+	// Set the main menu
+	w.SetMainMenu(fyne.NewMainMenu(
+		createFileMenu(w, theme),
+		createEditMenu(w),
+		createTabMenu(w, theme),
+		createHelpMenu(w)))
+
+	// files dragged onto the window open in tabs
+	w.SetOnDropped(func(pos fyne.Position, uris []fyne.URI) {
+		fileDropped(w, theme, pos, uris)
+	})
+
+	// Ctrl+S saves the current tab
+	w.Canvas().AddShortcut(&desktop.CustomShortcut{KeyName: fyne.KeyS, Modifier: fyne.KeyModifierControl},
+		func(fyne.Shortcut) {
+			if t := ew.current(); t != nil {
+				fileSave(w, t)
+			}
+		})
+
+	w.SetContent(ew.tabItems)
+	windows = append(windows, ew)
+	focused = ew
+	return ew
+}
+
+// windowOf finds the editorWindow of a window
+func windowOf(w fyne.Window) *editorWindow {
+	for _, ew := range windows {
+		if ew.window == w {
+			return ew
+		}
+	}
+	return focused
+}
+
+// current returns the selected tab, or nil when there are none
+func (ew *editorWindow) current() *tab {
+	if ew.tabix < len(ew.tabs) {
+		return ew.tabs[ew.tabix]
+	}
+	return nil
+}
+
+// unsavedTabs returns each tab of the window with unsaved changes
+func (ew *editorWindow) unsavedTabs() (unsaved []*tab) {
+	for _, t := range ew.tabs {
+		if t.editor.Modified() {
+			unsaved = append(unsaved, t)
+		}
+	}
+	return unsaved
+}
+
+// close closes the window and its tabs, without asking to save.
+// The last window keeps its tabs as the session.
+func (ew *editorWindow) close() {
+	for _, t := range ew.tabs {
+		tabClosed(t.item)
+		removeJournal(t)
+		unwatchFile(t.path)
+		delete(tabMap, t.item)
+	}
+	delete(recentMenus, ew.window)
+	if len(windows) > 1 {
+		windows = slices.DeleteFunc(windows, func(o *editorWindow) bool {
+			return o == ew
+		})
+		if focused == ew {
+			focused = windows[0]
+		}
+	}
+	ew.window.Close()
+}
+
+// newEditorWindow opens a window with a new tab
+func newEditorWindow(theme *textlist.MyTheme) {
+	ew := newWindow(theme)
+	newTab(ew.window, theme)
+	ew.window.Show()
+}
+
+// moveToNewWindow moves a tab to a window of its own
+func moveToNewWindow(t *tab) {
+	if len(t.win.tabs) == 1 {
+		return // it has one
+	}
+	ew := newWindow(tabTheme)
+	moveToWindow(t, ew)
+	ew.window.Show()
+}
+
+// moveToWindow moves a tab, with its changes and undo, to another window.
+// A window left without tabs is closed.
+func moveToWindow(t *tab, to *editorWindow) {
+	from := t.win
+	if from == to {
+		return
+	}
+	from.tabItems.Remove(t.item)
+	t.win = to
+	t.editor.SetWindow(to.window)
+	to.tabItems.Append(t.item)
+	to.tabItems.Select(t.item)
+	focused = to
+	syncTabs()
+	if len(from.tabs) == 0 {
+		from.close()
+	}
+}